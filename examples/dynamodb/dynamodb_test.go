@@ -4,61 +4,32 @@ import (
     "log"
     "fmt"
     "testing"
-    "os"
     "github.com/mitchelldavis/go_localstack/pkg/localstack"
 
     "github.com/aws/aws-sdk-go/service/dynamodb"
     "github.com/aws/aws-sdk-go/service/dynamodbstreams"
 )
 
-// LOCALSTACK: A global reference to the Localstack object
-var LOCALSTACK *localstack.Localstack
-
 // In order to setup a single Localstack instance for all tests in a
 // test suite, the TestMain function allows a single place to wrap all
 // tests in setup and teardown logic.  
 // https://golang.org/pkg/testing/#hdr-Main
-func TestMain(t *testing.M) {
-    os.Exit(InitializeLocalstack(t))
-}
-
-// We create a seperate iniitalize function so we can call
-// `defer LOCALSTACK.Destroy()`
-func InitializeLocalstack(t *testing.M) int {
-    // Create the S3 Service definition
-    dynamodb, _ := localstack.NewLocalstackService("dynamodb")
-    dynamodbstreams, _ := localstack.NewLocalstackService("dynamodbstreams")
-
-    // Gather up all service definitions in a single collection.
-    // (Only one in this case.)
-    LOCALSTACK_SERVICES := &localstack.LocalstackServiceCollection {
-        *dynamodb,
-        *dynamodbstreams,
-    }
-
-    // Initialize the service
-    var err error
-    LOCALSTACK, err = localstack.NewSpecificLocalstack(
-        LOCALSTACK_SERVICES, 
-        "dynamotest", 
-        localstack.Localstack_Repository, 
-        localstack.Localstack_Tag)
+//
+// RunMain starts Localstack, runs the tests and always destroys the
+// container, even when a test panics or the tests are interrupted.
+func TestMain(m *testing.M) {
+    services, err := localstack.NewLocalstackServiceCollection("dynamodb", "dynamodbstreams")
     if err != nil {
-        log.Fatal(fmt.Sprintf("Unable to create the localstack instance: %s", err))
-    }
-    if LOCALSTACK == nil {
-        log.Fatal("LOCALSTACK was nil.")
+        log.Fatal(fmt.Sprintf("Unable to create the localstack services: %s", err))
     }
 
-    // Make sure we Destroy Localstack.  This method handles
-    // stopping and removing the docker container.
-    defer LOCALSTACK.Destroy()
-
-    // RUN TESTS HERE
-    return t.Run()
+    localstack.RunMain(m, services,
+        localstack.WithName("dynamotest"),
+        localstack.WithTag(localstack.Localstack_Tag))
 }
+
 func Test_Dynamodb(t *testing.T) {
-    svc := dynamodb.New(LOCALSTACK.CreateAWSSession())
+    svc := dynamodb.New(localstack.Current().CreateAWSSession())
     result, err := svc.ListTables(&dynamodb.ListTablesInput{ })
     if err != nil {
         t.Error(err)
@@ -69,7 +40,7 @@ func Test_Dynamodb(t *testing.T) {
     }
 }
 func Test_DynamoDBStreams(t *testing.T) {
-    svc := dynamodbstreams.New(LOCALSTACK.CreateAWSSession())
+    svc := dynamodbstreams.New(localstack.Current().CreateAWSSession())
     result, err := svc.ListStreams(&dynamodbstreams.ListStreamsInput{ })
     if err != nil {
         t.Error(err)
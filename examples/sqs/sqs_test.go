@@ -0,0 +1,61 @@
+package examples
+
+import (
+    "testing"
+    "github.com/mitchelldavis/go_localstack/pkg/localstack"
+
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// ForTest starts Localstack (or reuses the one started by a test running at
+// the same time), fails the test if it can't, and destroys it when the last
+// test using it finishes.  No TestMain is needed.
+func Test_SendReceive(t *testing.T) {
+    t.Parallel()
+    ls := localstack.ForTest(t, "sqs")
+    svc, err := ls.SQS()
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    queue, err := svc.CreateQueue(&sqs.CreateQueueInput {
+        QueueName: aws.String("examplequeue"),
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    _, err = svc.SendMessage(&sqs.SendMessageInput {
+        QueueUrl: queue.QueueUrl,
+        MessageBody: aws.String("Hello World"),
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    result, err := svc.ReceiveMessage(&sqs.ReceiveMessageInput {
+        QueueUrl: queue.QueueUrl,
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if len(result.Messages) != 1 || *result.Messages[0].Body != "Hello World" {
+        t.Errorf("The message was not received.  Received %v", result.Messages)
+    }
+}
+
+// Parallel tests that ask for the same services share a single container.
+func Test_ListQueues(t *testing.T) {
+    t.Parallel()
+    ls := localstack.ForTest(t, "sqs")
+    svc, err := ls.SQS()
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if _, err := svc.ListQueues(&sqs.ListQueuesInput{ }); err != nil {
+        t.Fatal(err)
+    }
+}
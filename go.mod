@@ -1,10 +1,11 @@
 module github.com/mitchelldavis/go_localstack
 
-go 1.12
+go 1.15
 
 require (
 	github.com/Nvveen/Gotty v0.0.0-20120604004816-cd527374f1e5 // indirect
 	github.com/aws/aws-sdk-go v1.20.15
+	github.com/aws/aws-sdk-go-v2 v1.21.0
 	github.com/cenkalti/backoff v2.1.1+incompatible // indirect
 	github.com/containerd/continuity v0.0.0-20181203112020-004b46473808 // indirect
 	github.com/docker/go-connections v0.4.0 // indirect
@@ -0,0 +1,73 @@
+package localstack
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+)
+
+// Localstack_Profile is the name of the profile written by WriteAWSConfig.
+const Localstack_Profile string = "localstack"
+
+// WriteAWSConfig writes an AWS config file and credentials file into the directory
+// given, which is created if needed.  Both contain a Localstack_Profile profile with
+// the region, dummy credentials and an endpoint for every service in Services.
+// With WithTLS, the profile trusts the CA.
+// The paths returned are meant for AWS_CONFIG_FILE and AWS_SHARED_CREDENTIALS_FILE.
+// Set AWS_PROFILE to Localstack_Profile as well to use the profile:
+//
+//     config, credentials, err := ls.WriteAWSConfig(dir)
+//     cmd := exec.Command("aws", "s3", "ls")
+//     cmd.Env = append(os.Environ(),
+//         "AWS_CONFIG_FILE=" + config,
+//         "AWS_SHARED_CREDENTIALS_FILE=" + credentials,
+//         "AWS_PROFILE=" + localstack.Localstack_Profile)
+//
+// NOTE:  Only recent versions of the AWS CLI and SDKs support service endpoints
+// in the config file.
+func (l *Localstack) WriteAWSConfig(dir string) (string, string, error) {
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return "", "", errors.New(fmt.Sprintf("Unable to create directory %s: %s", dir, err))
+    }
+
+    var endpoints []string
+    for serviceID, name := range endpointServicesV2 {
+        if l.Services.Contains(name) {
+            key := strings.ToLower(strings.Replace(serviceID, " ", "_", -1))
+            endpoints = append(endpoints, fmt.Sprintf("%s =\n  endpoint_url = %s\n", key, l.serviceURL(name)))
+        }
+    }
+    sort.Strings(endpoints)
+
+    config := new(bytes.Buffer)
+    fmt.Fprintf(config, "[profile %s]\n", Localstack_Profile)
+    fmt.Fprintf(config, "region = %s\n", Localstack_Region)
+    fmt.Fprintf(config, "services = %s\n", Localstack_Profile)
+    if path, ok := l.CACertificate(); ok {
+        fmt.Fprintf(config, "ca_bundle = %s\n", path)
+    }
+    fmt.Fprintf(config, "\n[services %s]\n", Localstack_Profile)
+    config.WriteString(strings.Join(endpoints, ""))
+
+    credentials := new(bytes.Buffer)
+    fmt.Fprintf(credentials, "[%s]\n", Localstack_Profile)
+    credentials.WriteString("aws_access_key_id = a\n")
+    credentials.WriteString("aws_secret_access_key = b\n")
+    credentials.WriteString("aws_session_token = c\n")
+
+    configPath := filepath.Join(dir, "config")
+    if err := ioutil.WriteFile(configPath, config.Bytes(), 0600); err != nil {
+        return "", "", errors.New(fmt.Sprintf("Unable to write %s: %s", configPath, err))
+    }
+    credentialsPath := filepath.Join(dir, "credentials")
+    if err := ioutil.WriteFile(credentialsPath, credentials.Bytes(), 0600); err != nil {
+        return "", "", errors.New(fmt.Sprintf("Unable to write %s: %s", credentialsPath, err))
+    }
+
+    return configPath, credentialsPath, nil
+}
@@ -0,0 +1,48 @@
+package localstack
+
+import (
+    "io/ioutil"
+    "os"
+    "strings"
+    "testing"
+    "github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+func Test_WriteAWSConfig(t *testing.T) {
+    dir, _ := ioutil.TempDir("", "awsconfig_test")
+    defer os.RemoveAll(dir)
+
+    logs, _ := NewLocalstackService("logs")
+    ls := strictLocalstack()
+    *ls.Services = append(*ls.Services, *logs)
+    ls.Resource.Container.NetworkSettings.Ports["4586/tcp"] = ls.Resource.Container.NetworkSettings.Ports["4572/tcp"]
+
+    configPath, credentialsPath, err := ls.WriteAWSConfig(dir)
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    config, _ := ioutil.ReadFile(configPath)
+    expected := "[profile localstack]\n" +
+        "region = us-east-1\n" +
+        "services = localstack\n" +
+        "\n" +
+        "[services localstack]\n" +
+        "cloudwatch_logs =\n" +
+        "  endpoint_url = http://1.0.0.0:9572\n" +
+        "s3 =\n" +
+        "  endpoint_url = http://1.0.0.0:9572\n"
+    if string(config) != expected {
+        t.Errorf("The config file was not correct.  Received\n%s", config)
+    }
+
+    if strings.Contains(string(config), "sqs") {
+        t.Error("Only the requested services should have an endpoint.")
+    }
+
+    // The SDK should be able to read the credentials.
+    value, err := credentials.NewSharedCredentials(credentialsPath, Localstack_Profile).Get()
+    if err != nil || value.AccessKeyID != "a" || value.SecretAccessKey != "b" {
+        t.Errorf("The credentials file was not correct.  Received %+v, %v", value, err)
+    }
+}
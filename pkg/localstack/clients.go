@@ -0,0 +1,112 @@
+package localstack
+
+import (
+    "crypto/x509"
+    "errors"
+    "fmt"
+    "net/http"
+    "sync"
+    "github.com/aws/aws-sdk-go/aws/session"
+    "github.com/aws/aws-sdk-go/service/dynamodb"
+    "github.com/aws/aws-sdk-go/service/kinesis"
+    "github.com/aws/aws-sdk-go/service/lambda"
+    "github.com/aws/aws-sdk-go/service/s3"
+    "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// clientCache holds the session and service clients shared by the accessors,
+// and the transport shared by the clients returned by HTTPClient.
+type clientCache struct {
+    mutex sync.Mutex
+    session *session.Session
+    clients map[string]interface{}
+
+    tlsMutex sync.Mutex
+    tlsPool *x509.CertPool
+    tlsTransport *http.Transport
+}
+
+// clientCacheLock guards creating the clientCache of a Localstack.
+var clientCacheLock sync.Mutex
+
+func (l *Localstack) clientCache() *clientCache {
+    clientCacheLock.Lock()
+    defer clientCacheLock.Unlock()
+
+    if l.clients == nil {
+        l.clients = &clientCache { clients: map[string]interface{}{ } }
+    }
+    return l.clients
+}
+
+// client returns the client of the service named, creating it the first time.
+func (l *Localstack) client(name string, create func(*session.Session) interface{}) (interface{}, error) {
+    if !l.Services.Contains(name) {
+        return nil, errors.New(fmt.Sprintf("The %s service was not requested from Localstack", name))
+    }
+
+    cache := l.clientCache()
+    cache.mutex.Lock()
+    defer cache.mutex.Unlock()
+
+    if client, ok := cache.clients[name]; ok {
+        return client, nil
+    }
+    if cache.session == nil {
+        sess, err := l.CreateAWSSessionWithOptions(SessionOptions{ })
+        if err != nil {
+            return nil, err
+        }
+        cache.session = sess
+    }
+
+    client := create(cache.session)
+    cache.clients[name] = client
+    return client, nil
+}
+
+// S3 returns an S3 client for Localstack.  Every call returns the same client,
+// and every accessor shares one session.  It returns an error if s3 wasn't requested.
+func (l *Localstack) S3() (*s3.S3, error) {
+    client, err := l.client("s3", func(sess *session.Session) interface{} { return s3.New(sess) })
+    if err != nil {
+        return nil, err
+    }
+    return client.(*s3.S3), nil
+}
+
+// SQS returns an SQS client for Localstack.  (See S3)
+func (l *Localstack) SQS() (*sqs.SQS, error) {
+    client, err := l.client("sqs", func(sess *session.Session) interface{} { return sqs.New(sess) })
+    if err != nil {
+        return nil, err
+    }
+    return client.(*sqs.SQS), nil
+}
+
+// DynamoDB returns a DynamoDB client for Localstack.  (See S3)
+func (l *Localstack) DynamoDB() (*dynamodb.DynamoDB, error) {
+    client, err := l.client("dynamodb", func(sess *session.Session) interface{} { return dynamodb.New(sess) })
+    if err != nil {
+        return nil, err
+    }
+    return client.(*dynamodb.DynamoDB), nil
+}
+
+// Kinesis returns a Kinesis client for Localstack.  (See S3)
+func (l *Localstack) Kinesis() (*kinesis.Kinesis, error) {
+    client, err := l.client("kinesis", func(sess *session.Session) interface{} { return kinesis.New(sess) })
+    if err != nil {
+        return nil, err
+    }
+    return client.(*kinesis.Kinesis), nil
+}
+
+// Lambda returns a Lambda client for Localstack.  (See S3)
+func (l *Localstack) Lambda() (*lambda.Lambda, error) {
+    client, err := l.client("lambda", func(sess *session.Session) interface{} { return lambda.New(sess) })
+    if err != nil {
+        return nil, err
+    }
+    return client.(*lambda.Lambda), nil
+}
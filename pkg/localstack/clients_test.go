@@ -0,0 +1,71 @@
+package localstack
+
+import (
+    "sync"
+    "testing"
+)
+
+func Test_S3_Memoized(t *testing.T) {
+    ls := strictLocalstack()
+
+    first, err := ls.S3()
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+    second, _ := ls.S3()
+    if first != second {
+        t.Error("The same client should be returned every time.")
+    }
+
+    if first.Endpoint != "http://1.0.0.0:9572" {
+        t.Errorf("The client should use Localstack.  Received %s", first.Endpoint)
+    }
+}
+
+func Test_Accessors_NotRequested(t *testing.T) {
+    ls := strictLocalstack()
+
+    if _, err := ls.SQS(); err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+    if _, err := ls.DynamoDB(); err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+    if _, err := ls.Kinesis(); err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+    if _, err := ls.Lambda(); err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+}
+
+func Test_Accessors_SharedSession(t *testing.T) {
+    ls := strictLocalstack()
+    for _, name := range []string { "sqs", "dynamodb", "kinesis", "lambda" } {
+        service, _ := NewLocalstackService(name)
+        *ls.Services = append(*ls.Services, *service)
+    }
+
+    var wg sync.WaitGroup
+    for i := 0; i < 10; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            ls.S3()
+            ls.SQS()
+        }()
+    }
+    wg.Wait()
+
+    s3, _ := ls.S3()
+    sqs, _ := ls.SQS()
+    dynamodb, _ := ls.DynamoDB()
+    kinesis, _ := ls.Kinesis()
+    lambda, _ := ls.Lambda()
+    if s3.Config.Credentials != sqs.Config.Credentials ||
+       s3.Config.Credentials != dynamodb.Config.Credentials ||
+       s3.Config.Credentials != kinesis.Config.Credentials ||
+       s3.Config.Credentials != lambda.Config.Credentials {
+        t.Error("Every client should share one session.")
+    }
+}
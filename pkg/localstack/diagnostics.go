@@ -0,0 +1,121 @@
+package localstack
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "regexp"
+    "strings"
+    "testing"
+    "github.com/ory/dockertest/docker"
+)
+
+// DumpDiagnostics writes everything needed to debug a Localstack container into
+// the directory given, which is created if needed:
+//
+//     logs.txt        The full stdout and stderr of the container
+//     container.json  The output of InspectContainer
+//     env.txt         The environment of the container
+//     ports.json      The port mappings of the container
+//     services.json   The requested LocalstackServiceCollection
+//     health.json     The report from the health endpoint
+//
+// Every file that can be written is written, even when some fail.
+func (ls *Localstack) DumpDiagnostics(dir string) error {
+    return ls.DumpDiagnosticsContext(context.Background(), dir)
+}
+
+// DumpDiagnosticsContext is DumpDiagnostics with a context that can be used to cancel the call.
+func (ls *Localstack) DumpDiagnosticsContext(ctx context.Context, dir string) error {
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return errors.New(fmt.Sprintf("Unable to create diagnostics directory %s: %s", dir, err))
+    }
+
+    var failures []string
+    write := func(name string, content func() ([]byte, error)) {
+        data, err := content()
+        if err != nil {
+            failures = append(failures, fmt.Sprintf("%s: %s", name, err))
+            // Record why the content is missing next to where it would be.
+            data = []byte(err.Error())
+        }
+        if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+            failures = append(failures, fmt.Sprintf("%s: %s", name, err))
+        }
+    }
+
+    container := ls.Resource.Container
+    write("container.json", func() ([]byte, error) {
+        inspected, err := ls.dockerWrapper().InspectContainer(ctx, ls.Resource.Container.ID)
+        if err != nil {
+            return nil, err
+        }
+        container = inspected
+        return json.MarshalIndent(inspected, "", "    ")
+    })
+    write("logs.txt", func() ([]byte, error) {
+        buffer := new(bytes.Buffer)
+        err := ls.dockerWrapper().Logs(ctx, docker.LogsOptions {
+            Container: ls.Resource.Container.ID,
+            OutputStream: buffer,
+            ErrorStream: buffer,
+            Stdout: true,
+            Stderr: true,
+        })
+        return buffer.Bytes(), err
+    })
+    write("env.txt", func() ([]byte, error) {
+        if container == nil || container.Config == nil {
+            return nil, errors.New("The container configuration is not available")
+        }
+        return []byte(strings.Join(container.Config.Env, "\n") + "\n"), nil
+    })
+    write("ports.json", func() ([]byte, error) {
+        if container == nil || container.NetworkSettings == nil {
+            return nil, errors.New("The container network settings are not available")
+        }
+        return json.MarshalIndent(container.NetworkSettings.Ports, "", "    ")
+    })
+    write("services.json", func() ([]byte, error) {
+        return json.MarshalIndent(ls.Services, "", "    ")
+    })
+    write("health.json", func() ([]byte, error) {
+        report, err := ls.Health(ctx)
+        if err != nil {
+            return nil, err
+        }
+        return json.MarshalIndent(report, "", "    ")
+    })
+
+    if len(failures) > 0 {
+        return errors.New(fmt.Sprintf("Unable to write every diagnostic to %s: %s", dir, strings.Join(failures, "; ")))
+    }
+    return nil
+}
+
+// unsafePathCharacters matches the characters of a test name that shouldn't be in a path.
+var unsafePathCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// DumpDiagnosticsOnFailure dumps the diagnostics of the container when the test
+// fails.  (See DumpDiagnostics)  They are written to a directory named after the
+// test inside the directory given, so every failing test gets its own.  This is
+// run when the test and its subtests finish, before cleanups registered earlier,
+// so call it after Destroy is registered with t.Cleanup.
+func (ls *Localstack) DumpDiagnosticsOnFailure(t testing.TB, dir string) {
+    t.Cleanup(func() {
+        if !t.Failed() {
+            return
+        }
+        target := filepath.Join(dir, unsafePathCharacters.ReplaceAllString(t.Name(), "_"))
+        if err := ls.DumpDiagnostics(target); err != nil {
+            t.Log(err)
+            return
+        }
+        t.Logf("Localstack diagnostics were written to %s", target)
+    })
+}
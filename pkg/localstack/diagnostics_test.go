@@ -0,0 +1,161 @@
+package localstack
+
+import (
+    "context"
+    "io/ioutil"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "github.com/ory/dockertest"
+    "github.com/ory/dockertest/docker"
+    "github.com/mitchelldavis/go_localstack/pkg/mock_localstack"
+    "github.com/golang/mock/gomock"
+)
+
+func Test_DumpDiagnostics(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(`{"services": {"sqs": "running"}}`))
+    }))
+    defer server.Close()
+
+    container := healthContainer(server)
+    container.ID = "DummyID"
+    container.Config = &docker.Config { Env: []string { "SERVICES=sqs" } }
+
+    sqs, _ := NewLocalstackService("sqs")
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+    ls := &Localstack {
+        Resource: &dockertest.Resource { Container: container },
+        Services: &LocalstackServiceCollection { *sqs },
+        wrapper: m,
+    }
+
+    m.
+    EXPECT().
+    InspectContainer(gomock.Any(), "DummyID").
+    Return(container, nil)
+
+    m.
+    EXPECT().
+    Logs(gomock.Any(), gomock.Any()).
+    DoAndReturn(func(ctx context.Context, opts docker.LogsOptions) error {
+        opts.OutputStream.Write([]byte("Ready.\n"))
+        return nil
+    })
+
+    dir, _ := ioutil.TempDir("", "diagnostics_test")
+    defer os.RemoveAll(dir)
+
+    if err := ls.DumpDiagnostics(dir); err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    expected := map[string]string {
+        "logs.txt": "Ready.",
+        "container.json": "DummyID",
+        "env.txt": "SERVICES=sqs",
+        "ports.json": "4566/tcp",
+        "services.json": "sqs",
+        "health.json": "running",
+    }
+    for name, content := range expected {
+        data, err := ioutil.ReadFile(filepath.Join(dir, name))
+        if err != nil {
+            t.Errorf("%s should be written: %s", name, err)
+            continue
+        }
+        if !strings.Contains(string(data), content) {
+            t.Errorf("%s should contain %s.  Received %s", name, content, data)
+        }
+    }
+}
+
+func Test_DumpDiagnostics_Partial(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    sqs, _ := NewLocalstackService("sqs")
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+    ls := &Localstack {
+        Resource: &dockertest.Resource { Container: &docker.Container { ID: "DummyID" } },
+        Services: &LocalstackServiceCollection { *sqs },
+        wrapper: m,
+    }
+
+    m.
+    EXPECT().
+    InspectContainer(gomock.Any(), "DummyID").
+    Return(nil, os.ErrNotExist)
+
+    m.
+    EXPECT().
+    Logs(gomock.Any(), gomock.Any()).
+    Return(nil)
+
+    dir, _ := ioutil.TempDir("", "diagnostics_test")
+    defer os.RemoveAll(dir)
+
+    if err := ls.DumpDiagnostics(dir); err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+
+    if _, err := os.Stat(filepath.Join(dir, "services.json")); err != nil {
+        t.Errorf("The diagnostics available should still be written: %s", err)
+    }
+}
+
+// failedT is a failed test that collects its cleanups.
+type failedT struct {
+    recordingT
+    cleanups []func()
+}
+
+func (f *failedT) Failed() bool { return true }
+func (f *failedT) Name() string { return "Test_Dummy/sub test" }
+func (f *failedT) Cleanup(cleanup func()) { f.cleanups = append(f.cleanups, cleanup) }
+
+func Test_DumpDiagnosticsOnFailure(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    sqs, _ := NewLocalstackService("sqs")
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+    ls := &Localstack {
+        Resource: &dockertest.Resource { Container: &docker.Container { ID: "DummyID" } },
+        Services: &LocalstackServiceCollection { *sqs },
+        wrapper: m,
+    }
+
+    m.
+    EXPECT().
+    InspectContainer(gomock.Any(), "DummyID").
+    Return(ls.Resource.Container, nil)
+
+    m.
+    EXPECT().
+    Logs(gomock.Any(), gomock.Any()).
+    Return(nil)
+
+    dir, _ := ioutil.TempDir("", "diagnostics_test")
+    defer os.RemoveAll(dir)
+
+    failed := &failedT { recordingT: recordingT { TB: t } }
+    ls.DumpDiagnosticsOnFailure(failed, dir)
+    if len(failed.cleanups) != 1 {
+        t.Fatal("The diagnostics should be dumped when the test finishes.")
+    }
+    failed.cleanups[0]()
+
+    if _, err := os.Stat(filepath.Join(dir, "Test_Dummy_sub_test", "services.json")); err != nil {
+        t.Errorf("The diagnostics should be written to a directory named after the test: %s", err)
+    }
+}
@@ -1,6 +1,7 @@
 package localstack
 
 import (
+    "context"
     "errors"
     "fmt"
     "time"
@@ -11,49 +12,194 @@ import (
 // DockerWrapper is used to abstract docker to make testing easier.
 // Each method of this interface simply wraps functionality that already
 // exists in the Client object of the github.com/ory/dockertest/docker library.
+// Every method takes a context.Context that is used to cancel the call.
 type DockerWrapper interface {
-    // See https://godoc.org/github.com/ory/dockertest/docker#Client.InspectContainer
-	InspectContainer(string) (*docker.Container, error)
+    // See https://godoc.org/github.com/ory/dockertest/docker#Client.InspectContainerWithContext
+	InspectContainer(context.Context, string) (*docker.Container, error)
     // See https://godoc.org/github.com/ory/dockertest/docker#Client.ListContainers
-	ListContainers(docker.ListContainersOptions) ([]docker.APIContainers, error)
-    // See https://godoc.org/github.com/ory/dockertest/docker#Client.RunWithOptions
-	RunWithOptions(*dockertest.RunOptions, ...func(*docker.HostConfig)) (*dockertest.Resource, error)
-    // See https://godoc.org/github.com/ory/dockertest/docker#Client.Retry
-	Retry(func() error) error
+	ListContainers(context.Context, docker.ListContainersOptions) ([]docker.APIContainers, error)
+    // See https://godoc.org/github.com/ory/dockertest#Pool.RunWithOptions
+	RunWithOptions(context.Context, *dockertest.RunOptions, ...func(*docker.HostConfig)) (*dockertest.Resource, error)
+    // See https://godoc.org/github.com/ory/dockertest/docker#Client.RemoveContainer
+	RemoveContainer(context.Context, docker.RemoveContainerOptions) error
+    // See https://godoc.org/github.com/ory/dockertest/docker#Client.StopContainerWithContext
+	StopContainer(context.Context, string, uint) error
+    // See https://godoc.org/github.com/ory/dockertest/docker#Client.StartContainerWithContext
+	StartContainer(context.Context, string) error
+    // See https://godoc.org/github.com/ory/dockertest/docker#Client.PauseContainer
+	PauseContainer(context.Context, string) error
+    // See https://godoc.org/github.com/ory/dockertest/docker#Client.UnpauseContainer
+	UnpauseContainer(context.Context, string) error
+    // See https://godoc.org/github.com/ory/dockertest/docker#Client.Logs
+	Logs(context.Context, docker.LogsOptions) error
+    // See https://godoc.org/github.com/ory/dockertest/docker#Client.InspectImage
+	InspectImage(context.Context, string) (*docker.Image, error)
+    // See https://godoc.org/github.com/ory/dockertest/docker#Client.PullImage
+	PullImage(context.Context, docker.PullImageOptions, docker.AuthConfiguration) error
+    // See https://godoc.org/github.com/ory/dockertest/docker#Client.CommitContainer
+	CommitContainer(context.Context, docker.CommitContainerOptions) (*docker.Image, error)
+    // Retry calls the function given until it returns nil or
+    // the context is done.
+	Retry(context.Context, func() error) error
 }
 
 type _DockerWrapper struct { }
 
-func (dw *_DockerWrapper) InspectContainer(id string) (*docker.Container, error) {
+func (dw *_DockerWrapper) InspectContainer(ctx context.Context, id string) (*docker.Container, error) {
 	client, err := docker.NewClientFromEnv()
 	if err != nil {
 		return nil, errors.New(fmt.Sprintf("Unable to create a docker client: %s", err))
 	}
-	return client.InspectContainer(id)
+	return client.InspectContainerWithContext(id, ctx)
 }
 
-func (dw *_DockerWrapper) ListContainers(options docker.ListContainersOptions) ([]docker.APIContainers, error) {
+func (dw *_DockerWrapper) ListContainers(ctx context.Context, options docker.ListContainersOptions) ([]docker.APIContainers, error) {
 	client, err := docker.NewClientFromEnv()
 	if err != nil {
 		return nil, errors.New(fmt.Sprintf("Unable to create a docker client: %s", err))
 	}
-	
+
+    options.Context = ctx
 	return client.ListContainers(options)
 }
 
-func (dw *_DockerWrapper) RunWithOptions(opts *dockertest.RunOptions, hcOpts ...func(*docker.HostConfig)) (*dockertest.Resource, error) {
+func (dw *_DockerWrapper) RunWithOptions(ctx context.Context, opts *dockertest.RunOptions, hcOpts ...func(*docker.HostConfig)) (*dockertest.Resource, error) {
 	pool, err := dockertest.NewPool("")
 	if err != nil {
 		return nil, errors.New(fmt.Sprintf("Could not connect to docker: %s", err))
 	}
-	return pool.RunWithOptions(opts, hcOpts...)
+
+    // dockertest doesn't accept a context, so the call is run on its own
+    // and abandoned if the context is done first.
+    type result struct {
+        resource *dockertest.Resource
+        err error
+    }
+    done := make(chan result, 1)
+    go func() {
+        resource, err := pool.RunWithOptions(opts, hcOpts...)
+        done <- result{ resource, err }
+    }()
+
+    select {
+    case r := <-done:
+        return r.resource, r.err
+    case <-ctx.Done():
+        // The container may still be created after we stop waiting
+        // for it.  Make sure it doesn't get left behind.
+        go func() {
+            if r := <-done; r.resource != nil {
+                pool.Purge(r.resource)
+            }
+        }()
+        return nil, ctx.Err()
+    }
 }
 
-func (dw *_DockerWrapper) Retry(op func() error) error {
-	pool, err := dockertest.NewPool("")
-    pool.MaxWait = time.Minute * 5
+func (dw *_DockerWrapper) RemoveContainer(ctx context.Context, opts docker.RemoveContainerOptions) error {
+	client, err := docker.NewClientFromEnv()
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to create a docker client: %s", err))
+	}
+    opts.Context = ctx
+	return client.RemoveContainer(opts)
+}
+
+func (dw *_DockerWrapper) StopContainer(ctx context.Context, id string, timeout uint) error {
+	client, err := docker.NewClientFromEnv()
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to create a docker client: %s", err))
+	}
+	return client.StopContainerWithContext(id, timeout, ctx)
+}
+
+func (dw *_DockerWrapper) StartContainer(ctx context.Context, id string) error {
+	client, err := docker.NewClientFromEnv()
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to create a docker client: %s", err))
+	}
+	return client.StartContainerWithContext(id, nil, ctx)
+}
+
+func (dw *_DockerWrapper) PauseContainer(ctx context.Context, id string) error {
+	client, err := docker.NewClientFromEnv()
 	if err != nil {
-		return errors.New(fmt.Sprintf("Could not connect to docker: %s", err))
+		return errors.New(fmt.Sprintf("Unable to create a docker client: %s", err))
 	}
-	return pool.Retry(op)
+    // The docker client doesn't support a context for this call.
+    if err := ctx.Err(); err != nil {
+        return err
+    }
+	return client.PauseContainer(id)
+}
+
+func (dw *_DockerWrapper) UnpauseContainer(ctx context.Context, id string) error {
+	client, err := docker.NewClientFromEnv()
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to create a docker client: %s", err))
+	}
+    // The docker client doesn't support a context for this call.
+    if err := ctx.Err(); err != nil {
+        return err
+    }
+	return client.UnpauseContainer(id)
+}
+
+func (dw *_DockerWrapper) Logs(ctx context.Context, opts docker.LogsOptions) error {
+	client, err := docker.NewClientFromEnv()
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to create a docker client: %s", err))
+	}
+    opts.Context = ctx
+	return client.Logs(opts)
+}
+
+func (dw *_DockerWrapper) InspectImage(ctx context.Context, name string) (*docker.Image, error) {
+	client, err := docker.NewClientFromEnv()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to create a docker client: %s", err))
+	}
+    // The docker client doesn't support a context for this call.
+    if err := ctx.Err(); err != nil {
+        return nil, err
+    }
+	return client.InspectImage(name)
+}
+
+func (dw *_DockerWrapper) PullImage(ctx context.Context, opts docker.PullImageOptions, auth docker.AuthConfiguration) error {
+	client, err := docker.NewClientFromEnv()
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to create a docker client: %s", err))
+	}
+    opts.Context = ctx
+	return client.PullImage(opts, auth)
+}
+
+func (dw *_DockerWrapper) CommitContainer(ctx context.Context, opts docker.CommitContainerOptions) (*docker.Image, error) {
+	client, err := docker.NewClientFromEnv()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to create a docker client: %s", err))
+	}
+    opts.Context = ctx
+	return client.CommitContainer(opts)
+}
+
+func (dw *_DockerWrapper) Retry(ctx context.Context, op func() error) error {
+    wait := time.Millisecond * 250
+    for {
+        err := op()
+        if err == nil {
+            return nil
+        }
+
+        select {
+        case <-ctx.Done():
+            return errors.New(fmt.Sprintf("%s: %s", ctx.Err(), err))
+        case <-time.After(wait):
+        }
+
+        if wait < time.Second * 5 {
+            wait = wait * 2
+        }
+    }
 }
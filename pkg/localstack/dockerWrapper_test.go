@@ -0,0 +1,40 @@
+package localstack
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+)
+
+func Test_DockerWrapper_Retry(t *testing.T) {
+    calls := 0
+    err := (&_DockerWrapper{ }).Retry(context.Background(), func() error {
+        calls++
+        if calls < 2 {
+            return errors.New("Not Ready")
+        }
+        return nil
+    })
+
+    if err != nil {
+        t.Errorf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if calls != 2 {
+        t.Errorf("The operation should have been called twice.  Called %d times.", calls)
+    }
+}
+
+func Test_DockerWrapper_Retry_ContextDone(t *testing.T) {
+    ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond * 100)
+    defer cancel()
+
+    err := (&_DockerWrapper{ }).Retry(ctx, func() error {
+        return errors.New("Not Ready")
+    })
+
+    if err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+}
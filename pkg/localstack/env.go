@@ -0,0 +1,53 @@
+package localstack
+
+import (
+    "fmt"
+    "os"
+    "os/exec"
+    "sort"
+    "strings"
+)
+
+// endpointEnvName returns the name of the environment variable that overrides the
+// endpoint of a service for the AWS CLI and SDKs.  (I.E. AWS_ENDPOINT_URL_DYNAMODB_STREAMS)
+func endpointEnvName(serviceID string) string {
+    return "AWS_ENDPOINT_URL_" + strings.ToUpper(strings.Replace(serviceID, " ", "_", -1))
+}
+
+// Env returns the environment variables a process needs to use Localstack, each in
+// the form KEY=value:  the region, dummy credentials and an AWS_ENDPOINT_URL_<SERVICE>
+// endpoint for every service in Services.  With WithTLS, AWS_CA_BUNDLE is the CA.
+// NOTE:  Only recent versions of the AWS CLI and SDKs support service endpoints
+// from the environment.  (See WriteAWSConfig for the config file equivalent)
+func (l *Localstack) Env() []string {
+    env := []string {
+        "AWS_REGION=" + Localstack_Region,
+        "AWS_DEFAULT_REGION=" + Localstack_Region,
+        "AWS_ACCESS_KEY_ID=a",
+        "AWS_SECRET_ACCESS_KEY=b",
+        "AWS_SESSION_TOKEN=c",
+    }
+
+    if path, ok := l.CACertificate(); ok {
+        env = append(env, "AWS_CA_BUNDLE=" + path)
+    }
+
+    var endpoints []string
+    for serviceID, name := range endpointServicesV2 {
+        if l.Services.Contains(name) {
+            endpoints = append(endpoints, fmt.Sprintf("%s=%s", endpointEnvName(serviceID), l.serviceURL(name)))
+        }
+    }
+    sort.Strings(endpoints)
+
+    return append(env, endpoints...)
+}
+
+// Command returns an exec.Cmd that runs the program given with the environment
+// from Env added to the environment of this process.
+// (I.E. ls.Command("aws", "s3", "ls"))
+func (l *Localstack) Command(name string, args ...string) *exec.Cmd {
+    cmd := exec.Command(name, args...)
+    cmd.Env = append(os.Environ(), l.Env()...)
+    return cmd
+}
@@ -0,0 +1,54 @@
+package localstack
+
+import (
+    "os"
+    "runtime"
+    "strings"
+    "testing"
+)
+
+func Test_Env(t *testing.T) {
+    dynamodbstreams, _ := NewLocalstackService("dynamodbstreams")
+    ls := strictLocalstack()
+    *ls.Services = append(*ls.Services, *dynamodbstreams)
+
+    env := ls.Env()
+    for _, expected := range []string {
+        "AWS_REGION=us-east-1",
+        "AWS_ACCESS_KEY_ID=a",
+        "AWS_ENDPOINT_URL_S3=http://1.0.0.0:9572",
+    } {
+        if !contains(env, expected) {
+            t.Errorf("%s should be in the environment.  Received %v", expected, env)
+        }
+    }
+
+    for _, variable := range env {
+        if strings.HasPrefix(variable, "AWS_ENDPOINT_URL_SQS=") {
+            t.Error("Only the requested services should have an endpoint.")
+        }
+        if strings.HasPrefix(variable, "AWS_ENDPOINT_URL_DYNAMODB_STREAMS=") {
+            return
+        }
+    }
+    t.Errorf("Service ids with spaces should use underscores.  Received %v", env)
+}
+
+func Test_Command(t *testing.T) {
+    if runtime.GOOS == "windows" {
+        t.Skip("The env command isn't available on windows.")
+    }
+
+    os.Setenv("AWS_REGION", "eu-west-1")
+    defer os.Unsetenv("AWS_REGION")
+
+    output, err := strictLocalstack().Command("env").Output()
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    lines := strings.Split(string(output), "\n")
+    if !contains(lines, "AWS_ENDPOINT_URL_S3=http://1.0.0.0:9572") || !contains(lines, "AWS_REGION=us-east-1") {
+        t.Errorf("The command should run with the Localstack environment.  Received %s", output)
+    }
+}
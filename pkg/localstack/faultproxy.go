@@ -0,0 +1,343 @@
+package localstack
+
+import (
+    "bytes"
+    "encoding/json"
+    "encoding/xml"
+    "errors"
+    "fmt"
+    "io/ioutil"
+    "net"
+    "net/http"
+    "net/http/httputil"
+    "net/url"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Fault is a failure injected by a FaultProxy into the requests it matches.
+// Latency is added first, then the request is dropped, answered with an error
+// or forwarded to Localstack.
+//
+//     // Throttle the first two PutItem calls.
+//     localstack.Fault {
+//         Service: "dynamodb",
+//         Operation: "PutItem",
+//         ErrorCode: "ProvisionedThroughputExceededException",
+//         Times: 2,
+//     }
+type Fault struct {
+    // Service is the Localstack name of the service matched.  (I.E. "dynamodb")
+    // Every service is matched when it is empty.
+    Service string
+    // Operation is the name of the operation matched.  (I.E. "PutItem")
+    // Every operation is matched when it is empty.  The operations of services
+    // that use REST protocols are only known for s3, lambda and apigateway,
+    // so requests to the others are only matched by an empty Operation.
+    Operation string
+    // Latency is how long to wait before handling the request.
+    Latency time.Duration
+    // Drop closes the connection without a response.
+    Drop bool
+    // StatusCode is the status of the error response.  The default is 400
+    // when ErrorCode is set.
+    StatusCode int
+    // ErrorCode is the AWS error code of the error response.
+    // (I.E. "ThrottlingException")  The default is derived from StatusCode.
+    ErrorCode string
+    // Message is the message of the error response.
+    Message string
+    // Times is the number of requests the fault is injected into.  Every
+    // request matched is faulted when it is 0.
+    Times int
+}
+
+// FaultProxy sits between the SDK and Localstack and injects faults into the
+// requests it forwards.  It is used to test retry and backoff code.
+//
+//     proxy := localstack.NewFaultProxy()
+//     ls, err := localstack.NewLocalstackWithOptions(services, localstack.WithFaultProxy(proxy))
+//     ...
+//     proxy.Inject(localstack.Fault { Service: "sqs", StatusCode: 503, Times: 1 })
+type FaultProxy struct {
+    mutex sync.Mutex
+    faults []*injectedFault
+    server *http.Server
+    url string
+    target func(string) string
+    transport http.RoundTripper
+}
+
+type injectedFault struct {
+    Fault
+    used int
+}
+
+// NewFaultProxy returns a FaultProxy without any faults.  (See WithFaultProxy)
+func NewFaultProxy() *FaultProxy {
+    return &FaultProxy{ }
+}
+
+// Inject adds faults to the proxy.  A request gets the first fault that
+// matches it, in the order they were added.
+func (p *FaultProxy) Inject(faults ...Fault) {
+    p.mutex.Lock()
+    defer p.mutex.Unlock()
+
+    for _, fault := range faults {
+        p.faults = append(p.faults, &injectedFault { Fault: fault })
+    }
+}
+
+// Clear removes every fault from the proxy.
+func (p *FaultProxy) Clear() {
+    p.mutex.Lock()
+    defer p.mutex.Unlock()
+
+    p.faults = nil
+}
+
+// URL returns the URL the proxy is reached on, or "" when it isn't running.
+func (p *FaultProxy) URL() string {
+    p.mutex.Lock()
+    defer p.mutex.Unlock()
+
+    return p.url
+}
+
+// start serves the proxy on a local port.  Requests are forwarded to the URL
+// target returns for the service they are for.
+func (p *FaultProxy) start(target func(string) string, transport http.RoundTripper) error {
+    p.mutex.Lock()
+    defer p.mutex.Unlock()
+
+    if p.server != nil {
+        return errors.New("Unable to start the fault proxy: it is already running")
+    }
+
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        return errors.New(fmt.Sprintf("Unable to start the fault proxy: %s", err))
+    }
+
+    p.target = target
+    p.transport = transport
+    p.url = fmt.Sprintf("http://%s", listener.Addr())
+    p.server = &http.Server { Handler: p }
+    go p.server.Serve(listener)
+    return nil
+}
+
+// close stops the proxy.  It can be started again afterwards.
+func (p *FaultProxy) close() error {
+    p.mutex.Lock()
+    defer p.mutex.Unlock()
+
+    if p.server == nil {
+        return nil
+    }
+    err := p.server.Close()
+    p.server = nil
+    p.url = ""
+    return err
+}
+
+// match returns the fault to inject into a request, if any.
+func (p *FaultProxy) match(service, operation string) *Fault {
+    p.mutex.Lock()
+    defer p.mutex.Unlock()
+
+    for _, fault := range p.faults {
+        if fault.Service != "" && fault.Service != service {
+            continue
+        }
+        if fault.Operation != "" && fault.Operation != operation {
+            continue
+        }
+        if fault.Times > 0 && fault.used >= fault.Times {
+            continue
+        }
+        fault.used++
+        result := fault.Fault
+        return &result
+    }
+    return nil
+}
+
+func (p *FaultProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    service, operation, err := identifyRequest(r)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadGateway)
+        return
+    }
+
+    if fault := p.match(service, operation); fault != nil {
+        if fault.Latency > 0 {
+            select {
+            case <-time.After(fault.Latency):
+            case <-r.Context().Done():
+                return
+            }
+        }
+
+        if fault.Drop {
+            if hijacker, ok := w.(http.Hijacker); ok {
+                if conn, _, err := hijacker.Hijack(); err == nil {
+                    conn.Close()
+                    return
+                }
+            }
+            panic(http.ErrAbortHandler)
+        }
+
+        if fault.StatusCode != 0 || fault.ErrorCode != "" {
+            writeFault(w, r, service, fault)
+            return
+        }
+    }
+
+    p.mutex.Lock()
+    target, transport := p.target, p.transport
+    p.mutex.Unlock()
+
+    upstream, err := url.Parse(target(service))
+    if err != nil || upstream.Host == "" {
+        http.Error(w, fmt.Sprintf("Unable to find the %s service in Localstack", service), http.StatusBadGateway)
+        return
+    }
+    proxy := &httputil.ReverseProxy {
+        Director: func(req *http.Request) {
+            req.URL.Scheme = upstream.Scheme
+            req.URL.Host = upstream.Host
+        },
+        Transport: transport,
+    }
+    proxy.ServeHTTP(w, r)
+}
+
+// identifyRequest returns the Localstack name of the service a request is for,
+// from the scope it was signed with, and the name of its operation, if known.
+func identifyRequest(r *http.Request) (string, string, error) {
+    service := ""
+    credential := r.URL.Query().Get("X-Amz-Credential")
+    if auth := r.Header.Get("Authorization"); strings.Contains(auth, "Credential=") {
+        credential = strings.SplitN(strings.SplitN(auth, "Credential=", 2)[1], ",", 2)[0]
+    }
+    // The scope is key/date/region/service/aws4_request
+    if scope := strings.Split(credential, "/"); len(scope) == 5 {
+        service = scope[3]
+        if name, ok := endpointServices[service]; ok {
+            service = name
+        }
+    }
+
+    // JSON protocol.  (I.E. "DynamoDB_20120810.PutItem")
+    if target := r.Header.Get("X-Amz-Target"); target != "" {
+        if strings.HasPrefix(target, "DynamoDBStreams_") {
+            service = "dynamodbstreams"
+        }
+        return service, target[strings.LastIndex(target, ".") + 1:], nil
+    }
+
+    // Query protocol.  The body has to be put back for Localstack.
+    operation := r.URL.Query().Get("Action")
+    if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+        body, err := ioutil.ReadAll(r.Body)
+        if err != nil {
+            return "", "", errors.New(fmt.Sprintf("Unable to read the request: %s", err))
+        }
+        r.Body.Close()
+        r.Body = ioutil.NopCloser(bytes.NewReader(body))
+        if form, err := url.ParseQuery(string(body)); err == nil && form.Get("Action") != "" {
+            operation = form.Get("Action")
+        }
+    }
+    if operation != "" {
+        return service, operation, nil
+    }
+
+    // REST protocols.
+    return service, restOperationName(service, r), nil
+}
+
+// writeFault answers a request with the error of a fault, in the format the
+// SDK expects from the service.
+func writeFault(w http.ResponseWriter, r *http.Request, service string, fault *Fault) {
+    status := fault.StatusCode
+    if status == 0 {
+        status = http.StatusBadRequest
+    }
+    code := fault.ErrorCode
+    if code == "" {
+        code = strings.Replace(http.StatusText(status), " ", "", -1)
+    }
+    message := fault.Message
+    if message == "" {
+        message = fmt.Sprintf("%s injected by the fault proxy", code)
+    }
+
+    switch {
+    case r.Header.Get("X-Amz-Target") != "" || strings.Contains(r.Header.Get("Content-Type"), "json"):
+        body, _ := json.Marshal(map[string]string { "__type": code, "message": message })
+        w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+        w.Header().Set("X-Amzn-Errortype", code)
+        w.WriteHeader(status)
+        w.Write(body)
+    case service == "s3":
+        body, _ := xml.Marshal(struct {
+            XMLName xml.Name `xml:"Error"`
+            Code string
+            Message string
+        }{ Code: code, Message: message })
+        w.Header().Set("Content-Type", "application/xml")
+        w.WriteHeader(status)
+        w.Write(body)
+    default:
+        body, _ := xml.Marshal(struct {
+            XMLName xml.Name `xml:"ErrorResponse"`
+            Code string `xml:"Error>Code"`
+            Message string `xml:"Error>Message"`
+        }{ Code: code, Message: message })
+        w.Header().Set("Content-Type", "text/xml")
+        w.WriteHeader(status)
+        w.Write(body)
+    }
+}
+
+// faultProxy returns the proxy given with WithFaultProxy, if any.
+func (ls *Localstack) faultProxy() *FaultProxy {
+    if ls.options == nil {
+        return nil
+    }
+    return ls.options.faultProxy
+}
+
+// internal returns a copy of the Localstack for its own requests (I.E. Reset).
+// They reach the container directly, so the faults aren't injected into them,
+// and they aren't captured by the recorders given with WithRecorder.
+func (ls *Localstack) internal() *Localstack {
+    if ls.options == nil || (ls.options.faultProxy == nil && len(ls.options.recorders) == 0) {
+        return ls
+    }
+    direct := *ls
+    options := *ls.options
+    options.faultProxy = nil
+    options.recorders = nil
+    direct.options = &options
+    return &direct
+}
+
+// startFaultProxy starts the proxy given with WithFaultProxy so it forwards
+// to the container.
+func (ls *Localstack) startFaultProxy() error {
+    proxy := ls.faultProxy()
+    if proxy == nil {
+        return nil
+    }
+    client, err := ls.HTTPClient()
+    if err != nil {
+        return err
+    }
+    return proxy.start(ls.directURL, client.Transport)
+}
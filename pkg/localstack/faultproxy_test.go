@@ -0,0 +1,242 @@
+package localstack
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/awserr"
+    "github.com/aws/aws-sdk-go/aws/endpoints"
+    "github.com/aws/aws-sdk-go/aws/session"
+    "github.com/aws/aws-sdk-go/service/dynamodb"
+    "github.com/aws/aws-sdk-go/service/s3"
+    "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// faultLocalstack returns a Localstack whose services are reached through a
+// running fault proxy.  It also returns the number of requests the server got.
+func faultLocalstack(t *testing.T, names ...string) (*Localstack, *FaultProxy, *int, func()) {
+    forwarded := 0
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        forwarded++
+        if r.Header.Get("X-Amz-Target") != "" {
+            w.Write([]byte(`{}`))
+            return
+        }
+        w.Write([]byte(`<CreateQueueResponse><CreateQueueResult><QueueUrl>http://queue</QueueUrl></CreateQueueResult></CreateQueueResponse>`))
+    }))
+
+    proxy := NewFaultProxy()
+    ls := resetLocalstack(server, names...)
+    ls.options = newLocalstackOptions(WithFaultProxy(proxy))
+    if err := ls.startFaultProxy(); err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+    return ls, proxy, &forwarded, func() {
+        proxy.close()
+        server.Close()
+    }
+}
+
+func faultSession(t *testing.T, ls *Localstack) *session.Session {
+    sess, err := ls.CreateAWSSessionWithOptions(SessionOptions { MaxRetries: aws.Int(0) })
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+    return sess
+}
+
+func Test_FaultProxy_Endpoint(t *testing.T) {
+    ls, proxy, _, done := faultLocalstack(t, "sqs")
+    defer done()
+
+    ep, err := ls.EndpointFor(endpoints.SqsServiceID, "us-east-1")
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+    if ep.URL != proxy.URL() || ep.URL == ls.directURL("sqs") {
+        t.Errorf("The endpoint should be the proxy.  Received %s", ep.URL)
+    }
+}
+
+func Test_FaultProxy_Throttle(t *testing.T) {
+    ls, proxy, forwarded, done := faultLocalstack(t, "dynamodb")
+    defer done()
+
+    proxy.Inject(Fault {
+        Service: "dynamodb",
+        Operation: "PutItem",
+        ErrorCode: "ProvisionedThroughputExceededException",
+        Times: 1,
+    })
+    svc := dynamodb.New(faultSession(t, ls))
+    input := &dynamodb.PutItemInput {
+        TableName: aws.String("table"),
+        Item: map[string]*dynamodb.AttributeValue { "id": { S: aws.String("1") } },
+    }
+
+    _, err := svc.PutItem(input)
+    if aerr, ok := err.(awserr.RequestFailure); !ok || aerr.Code() != "ProvisionedThroughputExceededException" || aerr.StatusCode() != 400 {
+        t.Fatalf("The request should be throttled.  Received %v", err)
+    }
+    if *forwarded != 0 {
+        t.Errorf("A faulted request should not reach Localstack.  Received %d", *forwarded)
+    }
+
+    if _, err := svc.DescribeTable(&dynamodb.DescribeTableInput { TableName: aws.String("table") }); err != nil {
+        t.Errorf("Other operations should not be faulted: %s", err)
+    }
+    if _, err := svc.PutItem(input); err != nil {
+        t.Errorf("The fault should only be injected once: %s", err)
+    }
+    if *forwarded != 2 {
+        t.Errorf("The requests should be forwarded to Localstack.  Received %d", *forwarded)
+    }
+}
+
+func Test_FaultProxy_ServerError(t *testing.T) {
+    ls, proxy, _, done := faultLocalstack(t, "sqs")
+    defer done()
+
+    proxy.Inject(Fault { Service: "sqs", Operation: "CreateQueue", StatusCode: http.StatusServiceUnavailable })
+    svc := sqs.New(faultSession(t, ls))
+
+    _, err := svc.CreateQueue(&sqs.CreateQueueInput { QueueName: aws.String("queue") })
+    if aerr, ok := err.(awserr.RequestFailure); !ok || aerr.Code() != "ServiceUnavailable" || aerr.StatusCode() != 503 {
+        t.Fatalf("The request should fail with a 503.  Received %v", err)
+    }
+
+    proxy.Clear()
+    if _, err := svc.CreateQueue(&sqs.CreateQueueInput { QueueName: aws.String("queue") }); err != nil {
+        t.Errorf("Clear should remove every fault: %s", err)
+    }
+}
+
+func Test_FaultProxy_Drop(t *testing.T) {
+    ls, proxy, forwarded, done := faultLocalstack(t, "sqs")
+    defer done()
+
+    proxy.Inject(Fault { Service: "sqs", Drop: true })
+
+    _, err := sqs.New(faultSession(t, ls)).CreateQueue(&sqs.CreateQueueInput { QueueName: aws.String("queue") })
+    if err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+    if *forwarded != 0 {
+        t.Errorf("A dropped request should not reach Localstack.  Received %d", *forwarded)
+    }
+}
+
+func Test_FaultProxy_Latency(t *testing.T) {
+    ls, proxy, forwarded, done := faultLocalstack(t, "sqs")
+    defer done()
+
+    proxy.Inject(Fault { Operation: "CreateQueue", Latency: time.Millisecond * 100 })
+
+    start := time.Now()
+    if _, err := sqs.New(faultSession(t, ls)).CreateQueue(&sqs.CreateQueueInput { QueueName: aws.String("queue") }); err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+    if elapsed := time.Since(start); elapsed < time.Millisecond * 100 {
+        t.Errorf("The request should be delayed.  Received %s", elapsed)
+    }
+    if *forwarded != 1 {
+        t.Errorf("A delayed request should reach Localstack.  Received %d", *forwarded)
+    }
+}
+
+func Test_FaultProxy_RESTOperation(t *testing.T) {
+    ls, proxy, forwarded, done := faultLocalstack(t, "s3")
+    defer done()
+
+    proxy.Inject(Fault { Service: "s3", Operation: "PutObject", StatusCode: http.StatusInternalServerError })
+    svc := s3.New(faultSession(t, ls))
+
+    _, err := svc.PutObject(&s3.PutObjectInput { Bucket: aws.String("bucket"), Key: aws.String("key") })
+    if aerr, ok := err.(awserr.RequestFailure); !ok || aerr.StatusCode() != 500 {
+        t.Fatalf("The request should fail with a 500.  Received %v", err)
+    }
+
+    if _, err := svc.HeadObject(&s3.HeadObjectInput { Bucket: aws.String("bucket"), Key: aws.String("key") }); err != nil {
+        t.Errorf("Other operations should not be faulted: %s", err)
+    }
+    if *forwarded != 1 {
+        t.Errorf("Only the other operation should reach Localstack.  Received %d", *forwarded)
+    }
+}
+
+func Test_FaultProxy_ResetIsNotFaulted(t *testing.T) {
+    ls, proxy, forwarded, done := faultLocalstack(t, "sqs")
+    defer done()
+
+    proxy.Inject(Fault { Drop: true })
+
+    if err := ls.Reset("sqs"); err != nil {
+        t.Errorf("Reset should reach Localstack directly: %s", err)
+    }
+    if *forwarded != 1 {
+        t.Errorf("Reset should reach Localstack.  Received %d", *forwarded)
+    }
+}
+
+func Test_restOperationName(t *testing.T) {
+    tests := []struct {
+        service, method, path, header, operation string
+    }{
+        { "s3", "GET", "/", "", "ListBuckets" },
+        { "s3", "PUT", "/bucket", "", "CreateBucket" },
+        { "s3", "GET", "/bucket?list-type=2&prefix=a", "", "ListObjectsV2" },
+        { "s3", "GET", "/bucket?list-type=1", "", "ListObjects" },
+        { "s3", "GET", "/bucket?metrics&id=a", "", "GetBucketMetricsConfiguration" },
+        { "s3", "POST", "/bucket?delete", "", "DeleteObjects" },
+        { "s3", "PUT", "/bucket/a/b.txt", "", "PutObject" },
+        { "s3", "PUT", "/bucket/a/b.txt", "X-Amz-Copy-Source", "CopyObject" },
+        { "s3", "PUT", "/bucket/key?partNumber=1&uploadId=a", "", "UploadPart" },
+        { "s3", "PUT", "/bucket/key?partNumber=1&uploadId=a", "X-Amz-Copy-Source", "UploadPartCopy" },
+        { "s3", "POST", "/bucket/key?uploads", "", "CreateMultipartUpload" },
+        { "s3", "POST", "/bucket/key?uploadId=a", "", "CompleteMultipartUpload" },
+        { "s3", "DELETE", "/bucket/key?uploadId=a", "", "AbortMultipartUpload" },
+        { "lambda", "POST", "/2015-03-31/functions/name/invocations", "", "Invoke" },
+        { "lambda", "GET", "/2015-03-31/functions/", "", "ListFunctions" },
+        { "lambda", "GET", "/2015-03-31/functions/name", "", "GetFunction" },
+        { "lambda", "GET", "/2018-10-31/layers?find=LayerVersion&Arn=a", "", "GetLayerVersionByArn" },
+        { "apigateway", "PUT", "/restapis/id/resources/id/methods/GET/integration", "", "PutIntegration" },
+        { "apigateway", "PUT", "/tags/arn:aws:apigateway:us-east-1::%2Frestapis%2Fid", "", "TagResource" },
+        { "s3", "PATCH", "/bucket", "", "" },
+        { "sqs", "GET", "/", "", "" },
+    }
+    for _, test := range tests {
+        r := httptest.NewRequest(test.method, test.path, nil)
+        if test.header != "" {
+            r.Header.Set(test.header, "source")
+        }
+        if operation := restOperationName(test.service, r); operation != test.operation {
+            t.Errorf("%s %s should be %s.  Received %s", test.method, test.path, test.operation, operation)
+        }
+    }
+}
+
+func Test_IdentifyRequest(t *testing.T) {
+    tests := []struct {
+        credential, target, service, operation string
+    }{
+        { "a/20200101/us-east-1/dynamodb/aws4_request", "DynamoDB_20120810.PutItem", "dynamodb", "PutItem" },
+        { "a/20200101/us-east-1/dynamodb/aws4_request", "DynamoDBStreams_20120810.GetRecords", "dynamodbstreams", "GetRecords" },
+        { "a/20200101/us-east-1/monitoring/aws4_request", "", "cloudwatch", "" },
+        { "", "", "", "" },
+    }
+    for _, test := range tests {
+        r := httptest.NewRequest("POST", "/", nil)
+        if test.credential != "" {
+            r.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=" + test.credential + ", SignedHeaders=host, Signature=x")
+        }
+        if test.target != "" {
+            r.Header.Set("X-Amz-Target", test.target)
+        }
+        service, operation, err := identifyRequest(r)
+        if err != nil || service != test.service || operation != test.operation {
+            t.Errorf("The request should be for %s %s.  Received %s %s %v", test.service, test.operation, service, operation, err)
+        }
+    }
+}
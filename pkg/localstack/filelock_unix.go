@@ -0,0 +1,24 @@
+// +build !windows
+
+package localstack
+
+import (
+    "os"
+    "syscall"
+)
+
+// tryLockFile takes an exclusive lock on the file given without waiting.  It
+// returns false when another open file holds the lock.  The lock is released
+// by the system when the process exits.
+func tryLockFile(file *os.File) (bool, error) {
+    err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX | syscall.LOCK_NB)
+    if err == syscall.EWOULDBLOCK {
+        return false, nil
+    }
+    return err == nil, err
+}
+
+// unlockFile releases the lock taken by tryLockFile.
+func unlockFile(file *os.File) error {
+    return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
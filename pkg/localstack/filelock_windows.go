@@ -0,0 +1,53 @@
+// +build windows
+
+package localstack
+
+import (
+    "os"
+    "syscall"
+    "unsafe"
+)
+
+const (
+    lockfileFailImmediately = 0x00000001
+    lockfileExclusiveLock = 0x00000002
+    errorLockViolation syscall.Errno = 33
+)
+
+var (
+    kernel32 = syscall.NewLazyDLL("kernel32.dll")
+    procLockFileEx = kernel32.NewProc("LockFileEx")
+    procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+// tryLockFile takes an exclusive lock on the file given without waiting.  It
+// returns false when another open file holds the lock.  The lock is released
+// by the system when the process exits.
+func tryLockFile(file *os.File) (bool, error) {
+    overlapped := new(syscall.Overlapped)
+    result, _, err := procLockFileEx.Call(
+        file.Fd(),
+        lockfileExclusiveLock | lockfileFailImmediately,
+        0, 1, 0,
+        uintptr(unsafe.Pointer(overlapped)))
+    if result != 0 {
+        return true, nil
+    }
+    if err == errorLockViolation {
+        return false, nil
+    }
+    return false, err
+}
+
+// unlockFile releases the lock taken by tryLockFile.
+func unlockFile(file *os.File) error {
+    overlapped := new(syscall.Overlapped)
+    result, _, err := procUnlockFileEx.Call(
+        file.Fd(),
+        0, 1, 0,
+        uintptr(unsafe.Pointer(overlapped)))
+    if result == 0 {
+        return err
+    }
+    return nil
+}
@@ -0,0 +1,55 @@
+package localstack
+
+import (
+    "context"
+    "testing"
+)
+
+// ForTest returns a Localstack with the services named (I.E. "s3" or "sqs") for
+// the test given.  The container is shared (See WithShared), so tests running
+// at the same time that ask for the same services (I.E. parallel tests, or the
+// packages run by `go test ./...`) reuse one container.  It is destroyed once
+// the last of them finishes, so tests that run one after another each start
+// their own.  Use RunMain to keep a single container for a whole package.
+// The test fails immediately when Localstack can't be started.
+//
+//     func Test_Upload(t *testing.T) {
+//         t.Parallel()
+//         ls := localstack.ForTest(t, "s3")
+//         svc := s3.New(ls.CreateAWSSession())
+//         ...
+//     }
+func ForTest(t testing.TB, services ...string) *Localstack {
+    t.Helper()
+    return forTest(t, &_DockerWrapper{ }, services, WithShared())
+}
+
+// ForTestWithOptions is ForTest configured by the options given.  The container
+// is only shared when WithShared is given.
+func ForTestWithOptions(t testing.TB, services []string, opts ...Option) *Localstack {
+    t.Helper()
+    return forTest(t, &_DockerWrapper{ }, services, opts...)
+}
+
+func forTest(t testing.TB, wrapper DockerWrapper, names []string, opts ...Option) *Localstack {
+    t.Helper()
+
+    services, err := NewLocalstackServiceCollection(names...)
+    if err != nil {
+        t.Fatalf("Unable to create the Localstack services: %s", err)
+        return nil
+    }
+
+    ls, err := newLocalstack(context.Background(), services, wrapper, opts...)
+    if err != nil {
+        t.Fatalf("Unable to create the Localstack instance: %s", err)
+        return nil
+    }
+
+    t.Cleanup(func() {
+        if err := ls.Destroy(); err != nil {
+            t.Errorf("Unable to destroy the Localstack instance: %s", err)
+        }
+    })
+    return ls
+}
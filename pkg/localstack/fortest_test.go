@@ -0,0 +1,69 @@
+package localstack
+
+import (
+    "context"
+    "fmt"
+    "testing"
+    "github.com/ory/dockertest"
+    "github.com/ory/dockertest/docker"
+    "github.com/golang/mock/gomock"
+)
+
+// fatalT records a fatal failure instead of stopping the test.
+type fatalT struct {
+    failedT
+    fatal string
+}
+
+func (f *fatalT) Helper() { }
+func (f *fatalT) Errorf(format string, args ...interface{}) { f.fatal = fmt.Sprintf(format, args...) }
+func (f *fatalT) Fatalf(format string, args ...interface{}) { f.fatal = fmt.Sprintf(format, args...) }
+
+func Test_ForTest(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    sqs, _ := NewLocalstackService("sqs")
+    m := getLocalstack_Empty(&LocalstackServiceCollection { *sqs }, ctrl)
+
+    m.
+    EXPECT().
+    RunWithOptions(gomock.Any(), gomock.Any()).
+    Return(&dockertest.Resource { Container: &docker.Container { ID: "DummyID" } }, nil)
+
+    m.
+    EXPECT().
+    Retry(gomock.Any(), gomock.Any()).
+    Return(nil)
+
+    test := &fatalT { failedT: failedT { recordingT: recordingT { TB: t } } }
+    ls := forTest(test, m, []string { "sqs" }, WithName(Localstack_Name), WithPortMode(PortModeEdge))
+    if ls == nil || test.fatal != "" {
+        t.Fatalf("The test should not fail.  Received %s", test.fatal)
+    }
+
+    if len(test.cleanups) != 1 {
+        t.Fatal("Localstack should be destroyed when the test finishes.")
+    }
+
+    m.
+    EXPECT().
+    RemoveContainer(gomock.Any(), gomock.Any()).
+    Times(1).
+    Do(func(ctx context.Context, opts docker.RemoveContainerOptions) {
+        if opts.ID != "DummyID" {
+            t.Errorf("The wrong container was removed.  Received %s", opts.ID)
+        }
+    }).
+    Return(nil)
+
+    test.cleanups[0]()
+}
+
+func Test_ForTest_UnknownService(t *testing.T) {
+    test := &fatalT { failedT: failedT { recordingT: recordingT { TB: t } } }
+    if ls := forTest(test, nil, []string { "garbage" }); ls != nil || test.fatal == "" {
+        t.Error("The test should fail when a service is unknown.")
+    }
+}
@@ -0,0 +1,95 @@
+package localstack
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+)
+
+// Localstack_EdgePort is the port newer versions of Localstack serve
+// the health endpoint on.
+const Localstack_EdgePort string = "4566/tcp"
+
+// Localstack_HealthPaths are the paths of the health endpoint, from the newest
+// version of Localstack to the oldest.  The first one that responds is used.
+var Localstack_HealthPaths = []string {
+    "/_localstack/health",
+    "/health",
+}
+
+// HealthReport is the response from Localstack's health endpoint.
+type HealthReport struct {
+    // Services maps the name of each service to its status.
+    // (I.E. "s3": "running")
+    Services map[string]string `json:"services"`
+    // Version is the version of Localstack, if it was reported.
+    Version string `json:"version"`
+}
+
+// IsUp returns whether the named service is reported as up.
+func (report *HealthReport) IsUp(name string) bool {
+    switch report.Services[name] {
+    case "running", "available":
+        return true
+    default:
+        return false
+    }
+}
+
+// Down returns the names of the services in the collection that
+// aren't reported as up.
+func (report *HealthReport) Down(services *LocalstackServiceCollection) []string {
+    var down []string
+    for _, service := range *services {
+        if !report.IsUp(service.Name) {
+            down = append(down, service.Name)
+        }
+    }
+    return down
+}
+
+// Health retrieves the status of each service from Localstack's health endpoint.
+func (ls *Localstack) Health(ctx context.Context) (*HealthReport, error) {
+    hostPort := ls.Resource.GetHostPort(Localstack_EdgePort)
+    if hostPort == "" {
+        return nil, errors.New(fmt.Sprintf("The container doesn't expose the port %s", Localstack_EdgePort))
+    }
+
+    client, err := ls.HTTPClient()
+    if err != nil {
+        return nil, err
+    }
+    client.Timeout = time.Second * 5
+    for _, path := range Localstack_HealthPaths {
+        request, err := http.NewRequest("GET", fmt.Sprintf("%s://%s%s", ls.scheme(), hostPort, path), nil)
+        if err != nil {
+            return nil, err
+        }
+
+        response, err := client.Do(request.WithContext(ctx))
+        if err != nil {
+            return nil, errors.New(fmt.Sprintf("Unable to reach the health endpoint: %s", err))
+        }
+
+        if response.StatusCode == http.StatusNotFound {
+            response.Body.Close()
+            continue
+        }
+
+        report := &HealthReport{ }
+        err = json.NewDecoder(response.Body).Decode(report)
+        response.Body.Close()
+        if response.StatusCode != http.StatusOK {
+            return nil, errors.New(fmt.Sprintf("The health endpoint returned %s", response.Status))
+        }
+        if err != nil {
+            return nil, errors.New(fmt.Sprintf("Unable to read the health endpoint: %s", err))
+        }
+        return report, nil
+    }
+
+    return nil, errors.New("Unable to find the health endpoint")
+}
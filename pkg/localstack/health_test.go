@@ -0,0 +1,192 @@
+package localstack
+
+import (
+    "context"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "github.com/ory/dockertest"
+    "github.com/ory/dockertest/docker"
+    "github.com/golang/mock/gomock"
+)
+
+// healthContainer returns a container whose edge port is bound to the server given.
+func healthContainer(server *httptest.Server) *docker.Container {
+    host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+    return &docker.Container {
+        NetworkSettings: &docker.NetworkSettings {
+            Ports: map[docker.Port][]docker.PortBinding {
+                docker.Port(Localstack_EdgePort): []docker.PortBinding {docker.PortBinding { HostIP: host, HostPort: port }},
+            },
+        },
+    }
+}
+
+func Test_HealthReport_Down(t *testing.T) {
+    sqs, _ := NewLocalstackService("sqs")
+    s3, _ := NewLocalstackService("s3")
+    sns, _ := NewLocalstackService("sns")
+    services := &LocalstackServiceCollection {
+        *sqs,
+        *s3,
+        *sns,
+    }
+
+    report := &HealthReport {
+        Services: map[string]string {
+            "sqs": "running",
+            "s3": "available",
+            "sns": "initializing",
+        },
+    }
+
+    down := report.Down(services)
+    if len(down) != 1 || down[0] != "sns" {
+        t.Errorf("Only sns should be down.  Received %v", down)
+    }
+}
+
+func Test_Health(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/_localstack/health" {
+            t.Errorf("The wrong path was requested: %s", r.URL.Path)
+        }
+        w.Write([]byte(`{"services": {"s3": "running"}, "version": "1.4.0"}`))
+    }))
+    defer server.Close()
+
+    ls := &Localstack { Resource: &dockertest.Resource { Container: healthContainer(server) } }
+
+    report, err := ls.Health(context.Background())
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if !report.IsUp("s3") {
+        t.Error("s3 should be reported as up.")
+    }
+
+    if report.Version != "1.4.0" {
+        t.Errorf("The version was not correct.  Received %s", report.Version)
+    }
+}
+
+func Test_Health_LegacyPath(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/health" {
+            http.NotFound(w, r)
+            return
+        }
+        w.Write([]byte(`{"services": {"sqs": "running"}}`))
+    }))
+    defer server.Close()
+
+    ls := &Localstack { Resource: &dockertest.Resource { Container: healthContainer(server) } }
+
+    report, err := ls.Health(context.Background())
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if !report.IsUp("sqs") {
+        t.Error("sqs should be reported as up.")
+    }
+}
+
+func Test_Health_PortNotExposed(t *testing.T) {
+    ls := &Localstack { Resource: &dockertest.Resource { Container: &docker.Container { } } }
+
+    if _, err := ls.Health(context.Background()); err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+}
+
+func Test_NewLocalstack_ServiceNeverComesUp(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(`{"services": {"s3": "running", "sqs": "error"}}`))
+    }))
+    defer server.Close()
+
+    sqs, _ := NewLocalstackService("sqs")
+    s3, _ := NewLocalstackService("s3")
+    services := &LocalstackServiceCollection {
+        *sqs,
+        *s3,
+    }
+    m := getLocalstack_Empty(services, ctrl)
+
+    m.
+    EXPECT().
+    RunWithOptions(gomock.Any(), gomock.Any()).
+    Times(1).
+    Return(&dockertest.Resource { Container: healthContainer(server) }, nil)
+
+    m.
+    EXPECT().
+    Retry(gomock.Any(), gomock.Any()).
+    Times(1).
+    DoAndReturn(func(ctx context.Context, op func() error) error {
+        return op()
+    })
+
+    m.
+    EXPECT().
+    RemoveContainer(gomock.Any(), gomock.Any()).
+    Times(1).
+    Return(nil)
+
+    result, err := newLocalstack(context.Background(), services, m, append(testOptions, WithPortMode(PortModeEdge))...)
+
+    if result != nil {
+        t.Error("We were expecting the returned container to be nil.")
+    }
+
+    if err == nil || !strings.Contains(err.Error(), "came up: sqs") {
+        t.Errorf("The error should name the service that never came up.  Received %s", err)
+    }
+}
+
+func Test_NewLocalstack_LegacyImageReadyInLogs(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    sqs, _ := NewLocalstackService("sqs")
+    services := &LocalstackServiceCollection {
+        *sqs,
+    }
+    m := getLocalstack_Empty(services, ctrl)
+
+    m.
+    EXPECT().
+    RunWithOptions(gomock.Any(), gomock.Any()).
+    Times(1).
+    Return(&dockertest.Resource { Container: &docker.Container { ID: "DummyID" } }, nil)
+
+    m.
+    EXPECT().
+    Retry(gomock.Any(), gomock.Any()).
+    Times(1).
+    DoAndReturn(func(ctx context.Context, op func() error) error {
+        return op()
+    })
+
+    m.
+    EXPECT().
+    Logs(gomock.Any(), gomock.Any()).
+    Times(1).
+    DoAndReturn(func(ctx context.Context, opts docker.LogsOptions) error {
+        opts.OutputStream.Write([]byte("Starting mock services\nReady.\n"))
+        return nil
+    })
+
+    if _, err := newLocalstack(context.Background(), services, m, testOptions...); err != nil {
+        t.Errorf("Images without the edge port should be ready once their logs say so: %s", err)
+    }
+}
@@ -0,0 +1,101 @@
+package localstack
+
+import (
+    "context"
+    "errors"
+    "fmt"
+)
+
+// Localstack_StopTimeout is the number of seconds docker waits for Localstack
+// to stop before killing it.
+const Localstack_StopTimeout uint = 10
+
+// NOTE:  When the container is shared (See WithShared), these methods affect
+// every holder of the container.
+
+// Stop stops the Localstack container without removing it.
+// See Start to start it again.
+func (ls *Localstack) Stop() error {
+    return ls.StopContext(context.Background())
+}
+
+// StopContext stops the Localstack container without removing it.
+// The context given can be used to cancel the call.
+func (ls *Localstack) StopContext(ctx context.Context) error {
+    if err := ls.dockerWrapper().StopContainer(ctx, ls.Resource.Container.ID, Localstack_StopTimeout); err != nil {
+        return errors.New(fmt.Sprintf("Unable to stop container %s: %s", ls.Resource.Container.ID, err))
+    }
+    return nil
+}
+
+// Start starts a stopped Localstack container and waits for it to be ready.
+// Docker may bind the container to new host ports, so the Resource is refreshed
+// and endpoints resolve to the new ports.
+func (ls *Localstack) Start() error {
+    return ls.StartContext(context.Background())
+}
+
+// StartContext starts a stopped Localstack container and waits for it to be ready.
+// The context given can be used to cancel the call.
+func (ls *Localstack) StartContext(ctx context.Context) error {
+    if err := ls.dockerWrapper().StartContainer(ctx, ls.Resource.Container.ID); err != nil {
+        return errors.New(fmt.Sprintf("Unable to start container %s: %s", ls.Resource.Container.ID, err))
+    }
+    if err := ls.refresh(ctx); err != nil {
+        return err
+    }
+    return ls.waitForReady(ctx)
+}
+
+// Restart stops and then starts the Localstack container.  (See Start)
+func (ls *Localstack) Restart() error {
+    return ls.RestartContext(context.Background())
+}
+
+// RestartContext stops and then starts the Localstack container.
+// The context given can be used to cancel the call.
+func (ls *Localstack) RestartContext(ctx context.Context) error {
+    if err := ls.StopContext(ctx); err != nil {
+        return err
+    }
+    return ls.StartContext(ctx)
+}
+
+// Pause freezes every process in the Localstack container.  Calls to the
+// services will hang until Unpause is called.
+func (ls *Localstack) Pause() error {
+    return ls.PauseContext(context.Background())
+}
+
+// PauseContext freezes every process in the Localstack container.
+// The context given can be used to cancel the call.
+func (ls *Localstack) PauseContext(ctx context.Context) error {
+    if err := ls.dockerWrapper().PauseContainer(ctx, ls.Resource.Container.ID); err != nil {
+        return errors.New(fmt.Sprintf("Unable to pause container %s: %s", ls.Resource.Container.ID, err))
+    }
+    return nil
+}
+
+// Unpause resumes a paused Localstack container.
+func (ls *Localstack) Unpause() error {
+    return ls.UnpauseContext(context.Background())
+}
+
+// UnpauseContext resumes a paused Localstack container.
+// The context given can be used to cancel the call.
+func (ls *Localstack) UnpauseContext(ctx context.Context) error {
+    if err := ls.dockerWrapper().UnpauseContainer(ctx, ls.Resource.Container.ID); err != nil {
+        return errors.New(fmt.Sprintf("Unable to unpause container %s: %s", ls.Resource.Container.ID, err))
+    }
+    return nil
+}
+
+// refresh reloads the container so the Resource has its current host ports.
+func (ls *Localstack) refresh(ctx context.Context) error {
+    container, err := ls.dockerWrapper().InspectContainer(ctx, ls.Resource.Container.ID)
+    if err != nil {
+        return errors.New(fmt.Sprintf("Unable to inspect container %s: %s", ls.Resource.Container.ID, err))
+    }
+    ls.Resource.Container = container
+    return nil
+}
@@ -0,0 +1,152 @@
+package localstack
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "github.com/ory/dockertest"
+    "github.com/ory/dockertest/docker"
+    "github.com/mitchelldavis/go_localstack/pkg/mock_localstack"
+    "github.com/golang/mock/gomock"
+    "github.com/aws/aws-sdk-go/aws/endpoints"
+)
+
+func lifecycleContainer(hostPort string) *docker.Container {
+    return &docker.Container {
+        ID: "DummyID",
+        NetworkSettings: &docker.NetworkSettings {
+            Ports: map[docker.Port][]docker.PortBinding {
+                "4576/tcp": []docker.PortBinding {docker.PortBinding { HostIP: "1.0.0.0", HostPort: hostPort }},
+            },
+        },
+    }
+}
+
+func lifecycleLocalstack(m *mock_localstack.MockDockerWrapper) *Localstack {
+    sqs, _ := NewLocalstackService("sqs")
+    return &Localstack {
+        Resource: &dockertest.Resource { Container: lifecycleContainer("9576") },
+        Services: &LocalstackServiceCollection { *sqs },
+        wrapper: m,
+        portMode: PortModeLegacy,
+    }
+}
+
+func Test_Restart(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+    ls := lifecycleLocalstack(m)
+
+    gomock.InOrder(
+        m.
+        EXPECT().
+        StopContainer(gomock.Any(), "DummyID", Localstack_StopTimeout).
+        Return(nil),
+        m.
+        EXPECT().
+        StartContainer(gomock.Any(), "DummyID").
+        Return(nil),
+        m.
+        EXPECT().
+        InspectContainer(gomock.Any(), "DummyID").
+        Return(lifecycleContainer("10576"), nil),
+        m.
+        EXPECT().
+        Retry(gomock.Any(), gomock.Any()).
+        Return(nil),
+    )
+
+    // Sessions keep a copy of the Localstack, so they need to see the new ports too.
+    resolver := *ls
+
+    if err := ls.Restart(); err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    ep, _ := resolver.EndpointFor(endpoints.SqsServiceID, "us-east-1")
+    if ep.URL != "http://1.0.0.0:10576" {
+        t.Errorf("The endpoint should use the new host port.  Received %s", ep.URL)
+    }
+}
+
+func Test_Start_NeverReady(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+    ls := lifecycleLocalstack(m)
+
+    m.
+    EXPECT().
+    StartContainer(gomock.Any(), "DummyID").
+    Return(nil)
+
+    m.
+    EXPECT().
+    InspectContainer(gomock.Any(), "DummyID").
+    Return(lifecycleContainer("10576"), nil)
+
+    m.
+    EXPECT().
+    Retry(gomock.Any(), gomock.Any()).
+    Return(errors.New("Dummy Error"))
+
+    if err := ls.Start(); err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+}
+
+func Test_Stop_ReturnsError(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+    ls := lifecycleLocalstack(m)
+
+    m.
+    EXPECT().
+    StopContainer(gomock.Any(), "DummyID", gomock.Any()).
+    Return(errors.New("Dummy Error"))
+
+    m.
+    EXPECT().
+    StartContainer(gomock.Any(), gomock.Any()).
+    Times(0)
+
+    if err := ls.Restart(); err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+}
+
+func Test_PauseUnpause(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+    ls := lifecycleLocalstack(m)
+
+    gomock.InOrder(
+        m.
+        EXPECT().
+        PauseContainer(gomock.Any(), "DummyID").
+        Return(nil),
+        m.
+        EXPECT().
+        UnpauseContainer(gomock.Any(), "DummyID").
+        Return(nil),
+    )
+
+    if err := ls.PauseContext(context.Background()); err != nil {
+        t.Errorf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if err := ls.UnpauseContext(context.Background()); err != nil {
+        t.Errorf("We were expecting the returned error to be nil: %s", err)
+    }
+}
@@ -5,23 +5,24 @@ and manage a Localstack docker container for your go tests.
 
 Requirements
 
-    Go v1.11.0 or higher 
+    Go v1.15.0 or higher 
     Docker (Tested on version 19.03.0-rc Community Edition)
 */
 package localstack
 
 import (
+    "context"
 	"errors"
 	"fmt" 
-	"strings"
-	"bytes"
-	"bufio"
+    "os"
+    "path/filepath"
+    "time"
 	"github.com/ory/dockertest"
 	"github.com/ory/dockertest/docker"
 	"github.com/aws/aws-sdk-go/aws/endpoints"
     "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/aws/credentials"
 )
 
 // Localstack_Repository is the Localstack Docker repository
@@ -39,109 +40,119 @@ type Localstack struct {
     // Services is a pointer to a collection of service definitions
     // that are being requested from this particular instance of Localstack.
 	Services *LocalstackServiceCollection
+
+    wrapper DockerWrapper
+    shared *sharedHolder
+    portMode PortMode
+    options *localstackOptions
+    clients *clientCache
+}
+
+func (ls *Localstack) dockerWrapper() DockerWrapper {
+    if ls.wrapper == nil {
+        return &_DockerWrapper{ }
+    }
+    return ls.wrapper
 }
 
 // Destroy simply shuts down and cleans up the Localstack container out of docker.
 func (ls *Localstack) Destroy() error {
-	
-	pool, err := dockertest.NewPool("")
-	if err != nil {
-		return errors.New(fmt.Sprintf("Could not connect to docker: %s", err))
-	}
+	return ls.DestroyContext(context.Background())
+}
+
+// DestroyContext shuts down and cleans up the Localstack container out of docker.
+// The context given can be used to cancel the call.  A shared container
+// (See WithShared) is only cleaned up once its last holder is destroyed.
+func (ls *Localstack) DestroyContext(ctx context.Context) error {
+    if proxy := ls.faultProxy(); proxy != nil {
+        proxy.close()
+    }
+    if ls.shared != nil {
+        return ls.shared.release(ctx, func() error {
+            return ls.removeContainer(ctx)
+        })
+    }
+    return ls.removeContainer(ctx)
+}
+
+func (ls *Localstack) removeContainer(ctx context.Context) error {
 
 	// You can't defer this because os.Exit doesn't care for defer
-	if err := pool.Purge(ls.Resource); err != nil {
+	if err := ls.dockerWrapper().RemoveContainer(ctx, docker.RemoveContainerOptions {
+        ID: ls.Resource.Container.ID,
+        Force: true,
+        RemoveVolumes: true,
+    }); err != nil {
 		return errors.New(fmt.Sprintf("Could not purge resource: %s", err))
 	}
 
-	return nil
+    if err := ls.removeTLSDir(); err != nil {
+        return err
+    }
+    return ls.removeSnapshotDataDir()
 }
 
 // EndpointResolver is necessary to route traffic to AWS services in your code to the Localstack
 // endpoints.
 func (l Localstack) EndpointFor(service, region string, optFns ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
-    if service == endpoints.ApigatewayServiceID && 
-       l.Services.Contains("apigateway") {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4567/tcp")) }, nil
-    } else if service == endpoints.KinesisServiceID &&
-              l.Services.Contains("kinesis") {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4568/tcp")) }, nil
-    } else if service == endpoints.DynamodbServiceID &&
-              l.Services.Contains("dynamodb") {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4569/tcp")) }, nil
-    } else if service == endpoints.StreamsDynamodbServiceID &&
-              l.Services.Contains("dynamodbstreams")  {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4570/tcp")) }, nil
-    } else if service == endpoints.EsServiceID &&
-              l.Services.Contains("es")  {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4571/tcp")) }, nil
-    } else if service == endpoints.S3ServiceID &&
-              l.Services.Contains("s3")  {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4572/tcp")) }, nil
-    } else if service == endpoints.FirehoseServiceID &&
-              l.Services.Contains("firehose")  {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4573/tcp")) }, nil
-    } else if service == endpoints.LambdaServiceID &&
-              l.Services.Contains("lambda")  {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4574/tcp")) }, nil
-    } else if service == endpoints.SnsServiceID &&
-              l.Services.Contains("sns")  {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4575/tcp")) }, nil
-    } else if service == endpoints.SqsServiceID &&
-              l.Services.Contains("sqs")  {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4576/tcp")) }, nil
-    } else if service == endpoints.RedshiftServiceID &&
-              l.Services.Contains("redshift")  {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4577/tcp")) }, nil
-    } else if service == endpoints.EmailServiceID &&
-              l.Services.Contains("ses")  {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4579/tcp")) }, nil
-    } else if service == endpoints.Route53ServiceID &&
-              l.Services.Contains("route53")  {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4580/tcp")) }, nil
-    } else if service == endpoints.CloudformationServiceID &&
-              l.Services.Contains("cloudformation")  {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4581/tcp")) }, nil
-    } else if service == endpoints.MonitoringServiceID &&
-              l.Services.Contains("cloudwatch")  {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4582/tcp")) }, nil
-    } else if service == endpoints.SsmServiceID &&
-              l.Services.Contains("ssm")  {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4583/tcp")) }, nil
-    } else if service == endpoints.SecretsmanagerServiceID &&
-              l.Services.Contains("secretsmanager")  {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4584/tcp")) }, nil
-    } else if service == endpoints.StatesServiceID &&
-              l.Services.Contains("stepfunctions")  {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4585/tcp")) }, nil
-    } else if service == endpoints.LogsServiceID &&
-              l.Services.Contains("logs")  {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4586/tcp")) }, nil
-    } else if service == endpoints.StsServiceID &&
-              l.Services.Contains("sts")  {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4592/tcp")) }, nil
-    } else if service == endpoints.IamServiceID &&
-              l.Services.Contains("iam")  {
-        return endpoints.ResolvedEndpoint { URL: fmt.Sprintf("http://%s", l.Resource.GetHostPort("4593/tcp")) }, nil
-    } else {
-        return endpoints.DefaultResolver().EndpointFor(service, region, optFns...)
+    if name, ok := endpointServices[service]; ok && l.Services.Contains(name) {
+        return endpoints.ResolvedEndpoint { URL: l.serviceURL(name) }, nil
+    }
+    return endpoints.DefaultResolver().EndpointFor(service, region, optFns...)
+}
+
+// StrictEndpointFor resolves the requested services to Localstack like EndpointFor,
+// but returns an error naming the service for every other service instead of
+// resolving it to AWS.  This keeps a test that forgot to request a service from
+// sending requests to AWS.
+func (l Localstack) StrictEndpointFor(service, region string, optFns ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
+    name, ok := endpointServices[service]
+    if !ok {
+        return endpoints.ResolvedEndpoint{ }, errors.New(fmt.Sprintf("The %s service is not supported by Localstack", service))
+    }
+    if !l.Services.Contains(name) {
+        return endpoints.ResolvedEndpoint{ }, errors.New(fmt.Sprintf("The %s service was not requested from Localstack", name))
+    }
+    return l.EndpointFor(service, region, optFns...)
+}
+
+// strict returns whether sessions refuse services that weren't requested.
+// (See WithEndpointFallback)
+func (l *Localstack) strict() bool {
+    return l.options == nil || !l.options.endpointFallback
+}
+
+// resolver returns the endpoints.Resolver used by the sessions of this Localstack.
+func (l *Localstack) resolver() endpoints.Resolver {
+    if l.strict() {
+        return endpoints.ResolverFunc(l.StrictEndpointFor)
     }
+    return *l
+}
+
+// installStrictHandler makes requests fail with the error from StrictEndpointFor.
+// Otherwise the SDK reports a missing endpoint without saying why.
+func (l *Localstack) installStrictHandler(sess *session.Session) {
+    if !l.strict() {
+        return
+    }
+    sess.Handlers.Validate.PushFront(func(r *request.Request) {
+        if _, err := l.StrictEndpointFor(r.ClientInfo.ServiceName, aws.StringValue(r.Config.Region)); err != nil {
+            r.Error = err
+        }
+    })
 }
 
 // CreateAWSSession should be used to make sure that your AWS SDK traffic is routing to Localstack correctly.
+// Requests to services that weren't requested from Localstack fail.  (See StrictEndpointFor and WithEndpointFallback)
+// It panics when the session can't be created.  (See CreateAWSSessionWithOptions)
 func (l *Localstack) CreateAWSSession() *session.Session {
-	return session.Must(session.NewSession(&aws.Config{
-        Region: aws.String("us-east-1"),
-		EndpointResolver: *l,
-		DisableSSL: aws.Bool(true),
-		S3ForcePathStyle: aws.Bool(true),
-        Credentials: credentials.NewStaticCredentials("a", "b", "c"),
-	}))
+	return session.Must(l.CreateAWSSessionWithOptions(SessionOptions{ }))
 }
 
 // NewLocalstack creates a new Localstack docker container based on the latest version.
 func NewLocalstack(services *LocalstackServiceCollection) (*Localstack, error) {
-	return NewSpecificLocalstack(services, "", Localstack_Repository, "latest")
+	return NewLocalstackWithOptions(services)
 }
 
 // NewSpecificLocalstack creates a new Localstack docker container based on
@@ -150,13 +161,28 @@ func NewLocalstack(services *LocalstackServiceCollection) (*Localstack, error) {
 // to allow special situations like using a tag other than latest or when referencing 
 // an internal Localstack image.
 func NewSpecificLocalstack(services *LocalstackServiceCollection, name, repository, tag string) (*Localstack, error) {
-	return newLocalstack(services, &_DockerWrapper{ }, name, repository, tag)
+	return NewLocalstackWithOptions(services, WithName(name), WithRepository(repository), WithTag(tag))
+}
+
+// NewLocalstackWithOptions creates a new Localstack docker container configured
+// by the options given.  Without any options, this behaves like NewLocalstack.
+// NOTE:  When an existing container is reused (See WithName), options that only
+// apply when creating a container (I.E. WithEnv or WithMounts) are ignored.
+func NewLocalstackWithOptions(services *LocalstackServiceCollection, opts ...Option) (*Localstack, error) {
+	return NewLocalstackContext(context.Background(), services, opts...)
+}
+
+// NewLocalstackContext creates a new Localstack docker container configured by
+// the options given.  The context given can be used to cancel starting the
+// container and waiting for it to be ready.
+func NewLocalstackContext(ctx context.Context, services *LocalstackServiceCollection, opts ...Option) (*Localstack, error) {
+	return newLocalstack(ctx, services, &_DockerWrapper{ }, opts...)
 }
 
-func getLocalstack(services *LocalstackServiceCollection, dockerWrapper DockerWrapper, name, repository, tag string) (*dockertest.Resource, error) {
+func getLocalstack(ctx context.Context, services *LocalstackServiceCollection, dockerWrapper DockerWrapper, name, repository, tag string) (*dockertest.Resource, error) {
 
     if name != "" {
-        containers, err := dockerWrapper.ListContainers(docker.ListContainersOptions { All: true })
+        containers, err := dockerWrapper.ListContainers(ctx, docker.ListContainersOptions { All: true })
         if err != nil {
             return nil, errors.New(fmt.Sprintf("Unable to retrieve docker containers: %s", err))
         }
@@ -164,7 +190,7 @@ func getLocalstack(services *LocalstackServiceCollection, dockerWrapper DockerWr
             if c.Image == fmt.Sprintf("%s:%s", repository, tag) {
                 for _,internalName := range c.Names {
                     if internalName == fmt.Sprintf("/%s", name) {
-                        container, err := dockerWrapper.InspectContainer(c.ID)
+                        container, err := dockerWrapper.InspectContainer(ctx, c.ID)
                         if err !=  nil {
                             return nil, errors.New(fmt.Sprintf("Unable to inspect container %s: %s", c.ID, err))
                         }
@@ -178,76 +204,204 @@ func getLocalstack(services *LocalstackServiceCollection, dockerWrapper DockerWr
 	return nil, nil
 }
 
-func newLocalstack(services *LocalstackServiceCollection, wrapper DockerWrapper, name, repository, tag string) (*Localstack, error) {
+func pullLocalstack(ctx context.Context, wrapper DockerWrapper, options *localstackOptions) error {
+    switch options.pullPolicy {
+    case PullAlways:
+        err := wrapper.PullImage(ctx, docker.PullImageOptions {
+            Repository: options.repository,
+            Tag: options.tag,
+        }, docker.AuthConfiguration{ })
+        if err != nil {
+            return errors.New(fmt.Sprintf("Unable to pull image %s:%s: %s", options.repository, options.tag, err))
+        }
+    case PullNever:
+        if _, err := wrapper.InspectImage(ctx, fmt.Sprintf("%s:%s", options.repository, options.tag)); err != nil {
+            return errors.New(fmt.Sprintf("Image %s:%s is not available locally and will not be pulled: %s", options.repository, options.tag, err))
+        }
+    }
+
+    // PullIfMissing is handled by dockertest itself.
+    return nil
+}
+
+// startLocalstack finds an existing container to reuse or starts a new one.
+func startLocalstack(ctx context.Context, services *LocalstackServiceCollection, wrapper DockerWrapper, options *localstackOptions) (*dockertest.Resource, error) {
 
-	localstack, err := getLocalstack(services, wrapper, name, repository, tag)
+	localstack, err := getLocalstack(ctx, services, wrapper, options.name, options.repository, options.tag)
 	if err != nil {
 		return nil, err	
 	}
 
+    if localstack != nil {
+        localstack, err = reconcileLocalstack(ctx, wrapper, localstack, services, options.reusePolicy)
+        if err != nil {
+            return nil, err
+        }
+    }
+
 	if localstack == nil {
 
+        if err := pullLocalstack(ctx, wrapper, options); err != nil {
+            return nil, err
+        }
+
+        // Newer versions of Localstack don't accept a port for each service.
+        servicesEnv := services.GetServiceMap()
+        if mode, _ := tagPortMode(options.tag); options.portMode == PortModeEdge ||
+           (options.portMode == PortModeAuto && mode == PortModeEdge) {
+            servicesEnv = services.GetServiceNames()
+        }
+
+        env := append([]string{ }, options.env...)
+        mounts := append([]string{ }, options.mounts...)
+        dataDir, err := prepareDataDir(options)
+        if err != nil {
+            return nil, err
+        }
+        if dataDir != "" {
+            env = append(env, fmt.Sprintf("DATA_DIR=%s", Localstack_DataDir))
+            mounts = append(mounts, fmt.Sprintf("%s:%s", dataDir, Localstack_DataDir))
+        } else if options.persist {
+            env = append(env, fmt.Sprintf("DATA_DIR=%s", Localstack_DataDir))
+        }
+
+        tlsDir := ""
+        if options.tls {
+            if tlsDir, err = prepareTLS(); err != nil {
+                if dataDir != options.dataDir {
+                    os.RemoveAll(dataDir)
+                }
+                return nil, err
+            }
+            env = append(env, "USE_SSL=1", fmt.Sprintf("CUSTOM_SSL_CERT_PATH=%s", Localstack_CertFile))
+            mounts = append(mounts, fmt.Sprintf("%s:%s", filepath.Join(tlsDir, tlsServerFile), Localstack_CertFile))
+        }
+
+        labels := sessionLabels(time.Now())
+        for key, value := range options.labels {
+            labels[key] = value
+        }
+
 		// Fifth, If we didn't find a running container before, we spin one up now.
-		localstack, err = wrapper.RunWithOptions(&dockertest.RunOptions{
-			Repository: repository,
-			Tag: tag,
-            Name: name, //If name == "", docker ignores it.
-			Env: []string{
-				fmt.Sprintf("SERVICES=%s", services.GetServiceMap()),
-			},
-		})
+		localstack, err = wrapper.RunWithOptions(ctx, &dockertest.RunOptions{
+			Repository: options.repository,
+			Tag: options.tag,
+            Name: options.name, //If name == "", docker ignores it.
+			Env: append([]string{
+				fmt.Sprintf("SERVICES=%s", servicesEnv),
+			}, env...),
+            Labels: labels,
+            Mounts: mounts,
+		}, options.hostConfig...)
 		if err != nil {
+            if dataDir != options.dataDir {
+                os.RemoveAll(dataDir)
+            }
+            if tlsDir != "" {
+                os.RemoveAll(tlsDir)
+            }
 			return nil, errors.New(fmt.Sprintf("Could not start resource: %s", err))
 		}
 	}
 
-	// Sixth, we wait for the services to be ready before we allow the tests
-	// to be run.
-	for _, service := range *services {
-		if err := wrapper.Retry(func() error {
-
-			// We have to use a method that checks the output
-			// of the docker container here because simply checking for
-			// connetivity on the ports doesn't work.
-			client, err := docker.NewClientFromEnv()
-			if err != nil {
-				return errors.New(fmt.Sprintf("Unable to create a docker client: %s", err))
-			}
-
-			buffer := new(bytes.Buffer)
-
-			logsOptions := docker.LogsOptions {
-				Container: localstack.Container.ID,
-				OutputStream: buffer,
-				RawTerminal: true,
-				Stdout: true,
-				Stderr: true,
-			}
-			err = client.Logs(logsOptions)
-			if err != nil {
-				return errors.New(fmt.Sprintf("Unable to retrieve logs for container %s: %s", localstack.Container.ID, err))
-			}
-
-			scanner := bufio.NewScanner(buffer)
-			for scanner.Scan() {
-				token := strings.TrimSpace(scanner.Text())
-				expected := "Ready."
-				if strings.Contains(strings.TrimSpace(token),expected) {
-					return nil
-				}
-			}
-			if err := scanner.Err(); err != nil {
-				return errors.New(fmt.Sprintf("Reading input: %s", err))
-			}
-			return errors.New("Not Ready")
-		}); err != nil {
-			return nil, errors.New(fmt.Sprintf("Unable to connect to %s: %s", service.Name, err))
-		}
-	}
+    return localstack, nil
+}
+
+func newLocalstack(ctx context.Context, services *LocalstackServiceCollection, wrapper DockerWrapper, opts ...Option) (*Localstack, error) {
+
+    options := newLocalstackOptions(opts...)
+
+    if options.reapAge > 0 {
+        if _, err := reap(ctx, wrapper, options.reapAge, time.Now()); err != nil {
+            return nil, err
+        }
+    }
+
+    var shared *sharedHolder
+    var localstack *dockertest.Resource
+    var err error
+    if options.shared {
+        shared, err = newSharedHolder(services, options)
+        if err != nil {
+            return nil, err
+        }
+        if options.name == "" {
+            options.name = shared.name()
+        }
+        options.labels[Localstack_SharedLabel] = shared.key
+
+        localstack, err = shared.acquire(ctx, func() (*dockertest.Resource, error) {
+            return startLocalstack(ctx, services, wrapper, options)
+        })
+    } else {
+        localstack, err = startLocalstack(ctx, services, wrapper, options)
+    }
+    if err != nil {
+        return nil, err
+    }
 
-	return &Localstack{
+	result := &Localstack{
 		Resource: localstack,
 		Services: services,
-	}, nil
+        wrapper: wrapper,
+        shared: shared,
+        portMode: options.portMode,
+        options: options,
+	}
+
+	// Sixth, we wait for the services to be ready before we allow the tests
+	// to be run.
+    if err := result.waitForReady(ctx); err != nil {
+        return nil, result.abandon(err)
+    }
+
+    if result.portMode == PortModeAuto {
+        result.portMode = result.detectPortMode(ctx, options.tag)
+    }
+
+    if err := result.startFaultProxy(); err != nil {
+        return nil, result.abandon(err)
+    }
+
+	return result, nil
+}
+
+// Localstack_AbandonTimeout bounds how long removing a Localstack that failed
+// to start may take.  (See abandon)
+const Localstack_AbandonTimeout time.Duration = time.Minute
+
+// abandon destroys a Localstack that failed to start so its container and
+// temporary directories aren't left behind, and returns the error given with
+// the error of destroying it, if any.  The context given to newLocalstack may
+// already be done, so a new one bounded by Localstack_AbandonTimeout is used.
+func (ls *Localstack) abandon(cause error) error {
+    ctx, cancel := context.WithTimeout(context.Background(), Localstack_AbandonTimeout)
+    defer cancel()
+
+    if err := ls.DestroyContext(ctx); err != nil {
+        return errors.New(fmt.Sprintf("%s (Unable to remove Localstack: %s)", cause, err))
+    }
+    return cause
 }
 
+// waitForReady waits for the wait strategy to report that Localstack is ready.
+func (ls *Localstack) waitForReady(ctx context.Context) error {
+    options := ls.options
+    if options == nil {
+        options = newLocalstackOptions()
+    }
+
+    strategy := options.waitStrategy
+    if strategy == nil {
+        strategy = defaultWaitStrategy(ls.portMode)
+    }
+
+    readyCtx, cancel := context.WithTimeout(ctx, options.readinessTimeout)
+    defer cancel()
+    if err := ls.dockerWrapper().Retry(readyCtx, func() error {
+        return strategy.Ready(readyCtx, ls)
+    }); err != nil {
+        return errors.New(fmt.Sprintf("Localstack never became ready: %s", err))
+    }
+    return nil
+}
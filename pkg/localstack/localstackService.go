@@ -16,7 +16,8 @@ type LocalstackService struct {
     // Protocol is the network protocol used for communication.
 	Protocol string
     // Port is the port used when communicating with the service in the
-    // Localstack instance.
+    // Localstack instance.  Newer versions of Localstack serve every service
+    // on the edge port instead.  (See PortMode)
 	Port int
 }
 
@@ -184,8 +185,23 @@ func NewLocalstackService(name string) (*LocalstackService, error) {
 // LocalstackServiceCollection represents a collection of LocalstackService objects.
 type LocalstackServiceCollection []LocalstackService
 
+// NewLocalstackServiceCollection returns a collection of the services named.
+// (See NewLocalstackService)
+func NewLocalstackServiceCollection(names ...string) (*LocalstackServiceCollection, error) {
+	collection := LocalstackServiceCollection{}
+	for _, name := range names {
+		service, err := NewLocalstackService(name)
+		if err != nil {
+			return nil, err
+		}
+		collection = append(collection, *service)
+	}
+
+	return &collection, nil
+}
+
 // GetServiceMap returns a comma delimited string of all the AWS service
-// names in the collection.
+// names in the collection along with their ports.  (I.E. "s3:4572,sqs:4576")
 func (collection *LocalstackServiceCollection) GetServiceMap() string {
 	var maps []string
 	for _, element := range *collection {
@@ -194,6 +210,17 @@ func (collection *LocalstackServiceCollection) GetServiceMap() string {
 
 	return strings.Join(maps, ",")
 }
+
+// GetServiceNames returns a comma delimited string of the AWS service
+// names in the collection without their ports.
+func (collection *LocalstackServiceCollection) GetServiceNames() string {
+	var names []string
+	for _, element := range *collection {
+		names = append(names, element.Name)
+	}
+
+	return strings.Join(names, ",")
+}
 // Len returns the number of items in the collection.
 func (a LocalstackServiceCollection) Len() int { 
 	return len(a) 
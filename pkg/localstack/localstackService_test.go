@@ -136,6 +136,34 @@ func Test_LocalstackServiceCollection_GetServiceMap(t *testing.T) {
 	}
 }
 
+func Test_LocalstackServiceCollection_GetServiceNames(t *testing.T) {
+	sqs, _ := NewLocalstackService("sqs")
+	s3, _ := NewLocalstackService("s3")
+	collection := &LocalstackServiceCollection {
+		*sqs,
+		*s3,
+	}
+
+	if actual := collection.GetServiceNames(); actual != "sqs,s3" {
+		t.Errorf("The service names were not correct.  Received %s", actual)
+	}
+}
+
+func Test_NewLocalstackServiceCollection(t *testing.T) {
+	collection, err := NewLocalstackServiceCollection("sqs", "s3")
+	if err != nil {
+		t.Fatalf("We were expecting the returned error to be nil: %s", err)
+	}
+
+	if actual := collection.GetServiceMap(); actual != "sqs:4576,s3:4572" {
+		t.Errorf("The collection was not correct.  Received %s", actual)
+	}
+
+	if _, err := NewLocalstackServiceCollection("sqs", "garbage"); err == nil {
+		t.Error("We were expecting the returned error to be populated.")
+	}
+}
+
 func Test_LocalstackServiceCollection_Len(t *testing.T) {
 	first, _ := NewLocalstackService("sqs")
 	second, _ := NewLocalstackService("sns")
@@ -1,22 +1,32 @@
 package localstack
 
 import (
+    "context"
     "fmt"
     "errors"
     "log"
+    "strings"
     "testing"
+    "time"
     "github.com/ory/dockertest"
     "github.com/ory/dockertest/docker"
     "github.com/mitchelldavis/go_localstack/pkg/mock_localstack"
     "github.com/golang/mock/gomock"
     "github.com/aws/aws-sdk-go/aws"
     "github.com/aws/aws-sdk-go/aws/endpoints"
+    "github.com/aws/aws-sdk-go/service/sqs"
 ) 
 
 const (
     Localstack_Name = "testLocalstackName"
 )
 
+var testOptions = []Option {
+    WithName(Localstack_Name),
+    WithRepository(Localstack_Repository),
+    WithTag(Localstack_Tag),
+}
+
 func getLocalstack_Found(services *LocalstackServiceCollection, ctrl *gomock.Controller) (*mock_localstack.MockDockerWrapper, *docker.Container) {
     m := mock_localstack.NewMockDockerWrapper(ctrl)
     container := &docker.Container {
@@ -29,7 +39,7 @@ func getLocalstack_Found(services *LocalstackServiceCollection, ctrl *gomock.Con
 
     m.
     EXPECT().
-    ListContainers(gomock.Any()).
+    ListContainers(gomock.Any(), gomock.Any()).
     Times(1).
     Return([]docker.APIContainers {
         docker.APIContainers {
@@ -40,7 +50,7 @@ func getLocalstack_Found(services *LocalstackServiceCollection, ctrl *gomock.Con
 
     m.
     EXPECT().
-    InspectContainer(gomock.Any()).
+    InspectContainer(gomock.Any(), gomock.Any()).
     Times(1).
     Return(container, nil)
 
@@ -52,7 +62,7 @@ func getLocalstack_Empty(services *LocalstackServiceCollection, ctrl *gomock.Con
 
     m.
     EXPECT().
-    ListContainers(gomock.Any()).
+    ListContainers(gomock.Any(), gomock.Any()).
     Times(1).
     Return(nil, nil)
 
@@ -67,13 +77,13 @@ func Test_getLocalstack_ErrorWithListContainers(t *testing.T) {
 
     m.
     EXPECT().
-    ListContainers(gomock.Any()).
+    ListContainers(gomock.Any(), gomock.Any()).
     Times(1).
     Return(nil, errors.New("Dummy Error"))
 
     m.
     EXPECT().
-    InspectContainer(gomock.Any()).
+    InspectContainer(gomock.Any(), gomock.Any()).
     Times(0).
     Return(nil, nil)
 
@@ -81,7 +91,7 @@ func Test_getLocalstack_ErrorWithListContainers(t *testing.T) {
     services := &LocalstackServiceCollection {
         *sqs,
     }
-    actual, err := getLocalstack(services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
+    actual, err := getLocalstack(context.Background(), services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
 
     if actual != nil {
         log.Fatal("We're expecting the localstack result to be nil.")
@@ -100,7 +110,7 @@ func Test_getLocalstack_UnknownImage(t *testing.T) {
 
     m.
     EXPECT().
-    ListContainers(gomock.Any()).
+    ListContainers(gomock.Any(), gomock.Any()).
     Times(1).
     Return([]docker.APIContainers {
         docker.APIContainers {Image: "DummyImage:1.0.0"},
@@ -108,7 +118,7 @@ func Test_getLocalstack_UnknownImage(t *testing.T) {
 
     m.
     EXPECT().
-    InspectContainer(gomock.Any()).
+    InspectContainer(gomock.Any(), gomock.Any()).
     Times(0).
     Return(nil, nil)
 
@@ -116,7 +126,7 @@ func Test_getLocalstack_UnknownImage(t *testing.T) {
     services := &LocalstackServiceCollection {
         *sqs,
     }
-    actual, err := getLocalstack(services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
+    actual, err := getLocalstack(context.Background(), services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
 
     if actual != nil || err != nil {
         log.Fatal("We're expecting both the localstack and error return results to be nil.")
@@ -131,7 +141,7 @@ func Test_getLocalstack_ErrorWithInspectContainer(t *testing.T) {
 
     m.
     EXPECT().
-    ListContainers(gomock.Any()).
+    ListContainers(gomock.Any(), gomock.Any()).
     Times(1).
     Return([]docker.APIContainers {
         docker.APIContainers {
@@ -142,7 +152,7 @@ func Test_getLocalstack_ErrorWithInspectContainer(t *testing.T) {
 
     m.
     EXPECT().
-    InspectContainer(gomock.Any()).
+    InspectContainer(gomock.Any(), gomock.Any()).
     Times(1).
     Return(nil, errors.New("Dummy Error"))
 
@@ -150,7 +160,7 @@ func Test_getLocalstack_ErrorWithInspectContainer(t *testing.T) {
     services := &LocalstackServiceCollection {
         *sqs,
     }
-    actual, err := getLocalstack(services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
+    actual, err := getLocalstack(context.Background(), services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
 
     if actual != nil {
         log.Fatal("We're expecting the localstack result to be nil.")
@@ -170,7 +180,7 @@ func Test_getLocalstack_ContainerExistsButHasDifferentName(t *testing.T) {
     // Setup call to ListContainers
     m.
     EXPECT().
-    ListContainers(gomock.Any()).
+    ListContainers(gomock.Any(), gomock.Any()).
     Times(1).
     Return([]docker.APIContainers {
         docker.APIContainers {
@@ -181,14 +191,14 @@ func Test_getLocalstack_ContainerExistsButHasDifferentName(t *testing.T) {
 
     m.
     EXPECT().
-    InspectContainer(gomock.Any()).
+    InspectContainer(gomock.Any(), gomock.Any()).
     Times(0)
 
     sqs, _ := NewLocalstackService("sqs")
     services := &LocalstackServiceCollection {
         *sqs,
     }
-    actual, err := getLocalstack(services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
+    actual, err := getLocalstack(context.Background(), services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
 
     if actual != nil {
         log.Fatal("We're expecting the localstack result to be nil.")
@@ -207,13 +217,13 @@ func Test_getLocalstack_UnableToFindContainer(t *testing.T) {
 
     m.
     EXPECT().
-    ListContainers(gomock.Any()).
+    ListContainers(gomock.Any(), gomock.Any()).
     Times(1).
     Return(nil, nil)
 
     m.
     EXPECT().
-    InspectContainer(gomock.Any()).
+    InspectContainer(gomock.Any(), gomock.Any()).
     Times(0).
     Return(nil, nil)
 
@@ -221,7 +231,7 @@ func Test_getLocalstack_UnableToFindContainer(t *testing.T) {
     services := &LocalstackServiceCollection {
         *sqs,
     }
-    actual, err := getLocalstack(services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
+    actual, err := getLocalstack(context.Background(), services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
 
     if actual != nil || err != nil {
         log.Fatal("We're expecting both the localstack and error return results to be nil.")
@@ -238,7 +248,7 @@ func Test_getLocalstack_ContainerExists(t *testing.T) {
     }
     m, c := getLocalstack_Found(services, ctrl)
 
-    actual, err := getLocalstack(services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
+    actual, err := getLocalstack(context.Background(), services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
 
     if err != nil {
         log.Fatal("We're expecting the error returned to be nil.")	
@@ -269,7 +279,7 @@ func Test_getLocalstack_ContainerExists_WithinMultiple(t *testing.T) {
 
     m.
     EXPECT().
-    ListContainers(gomock.Any()).
+    ListContainers(gomock.Any(), gomock.Any()).
     Times(1).
     Return([]docker.APIContainers {
         docker.APIContainers {
@@ -284,11 +294,11 @@ func Test_getLocalstack_ContainerExists_WithinMultiple(t *testing.T) {
 
     m.
     EXPECT().
-    InspectContainer(gomock.Any()).
+    InspectContainer(gomock.Any(), gomock.Any()).
     Times(1).
     Return(container, nil)
 
-    actual, err := getLocalstack(services, m, "DummyContainer", Localstack_Repository, Localstack_Tag)
+    actual, err := getLocalstack(context.Background(), services, m, "DummyContainer", Localstack_Repository, Localstack_Tag)
 
     if err != nil {
         log.Fatal("We're expecting the error returned to be nil.")	
@@ -312,11 +322,11 @@ func Test_NewLocalstack_GetLocalstackReturnsError(t *testing.T) {
 
     m.
     EXPECT().
-    ListContainers(gomock.Any()).
+    ListContainers(gomock.Any(), gomock.Any()).
     Times(1).
     Return(nil, errors.New("Dummy Error"))
 
-    result, err := newLocalstack(services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
+    result, err := newLocalstack(context.Background(), services, m, testOptions...)
 
     if result != nil {
         log.Fatal("We were expecting the returned container to be nil.")
@@ -342,16 +352,16 @@ func Test_NewLocalstack_GetLocalstackReturnsResult(t *testing.T) {
 
     m.
     EXPECT().
-    RunWithOptions(gomock.Any()).
+    RunWithOptions(gomock.Any(), gomock.Any()).
     Times(0)
 
     m.
     EXPECT().
-    Retry(gomock.Any()).
-    Times(2).
+    Retry(gomock.Any(), gomock.Any()).
+    Times(1).
     Return(nil)
 
-    result, err := newLocalstack(services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
+    result, err := newLocalstack(context.Background(), services, m, testOptions...)
 
     if err != nil {
         log.Fatal("We were expecting the returned error to be nil.")
@@ -362,7 +372,7 @@ func Test_NewLocalstack_GetLocalstackReturnsResult(t *testing.T) {
     }
 }
 
-func Test_NewLocalstack_GetLocalstackReturnsResult_RetryFailsOnFirstService(t *testing.T) {
+func Test_NewLocalstack_GetLocalstackReturnsResult_RetryFails(t *testing.T) {
     ctrl := gomock.NewController(t)
 
     defer ctrl.Finish()	
@@ -377,22 +387,22 @@ func Test_NewLocalstack_GetLocalstackReturnsResult_RetryFailsOnFirstService(t *t
 
     m.
     EXPECT().
-    RunWithOptions(gomock.Any()).
+    RunWithOptions(gomock.Any(), gomock.Any()).
     Times(0)
 
-    gomock.InOrder(
-        m.
-        EXPECT().
-        Retry(gomock.Any()).
-        Return(errors.New("DummyError")),
-        m.
-        EXPECT().
-        Retry(gomock.Any()).
-        Times(0).
-        Return(nil),
-    )
+    m.
+    EXPECT().
+    Retry(gomock.Any(), gomock.Any()).
+    Times(1).
+    Return(errors.New("DummyError"))
 
-    result, err := newLocalstack(services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
+    m.
+    EXPECT().
+    RemoveContainer(gomock.Any(), gomock.Any()).
+    Times(1).
+    Return(errors.New("DummyRemoveError"))
+
+    result, err := newLocalstack(context.Background(), services, m, testOptions...)
 
     if result != nil {
         log.Fatal("We were expecting the returned container to be nil.")
@@ -401,45 +411,55 @@ func Test_NewLocalstack_GetLocalstackReturnsResult_RetryFailsOnFirstService(t *t
     if err == nil {
         log.Fatal("We were expecting the returned error to be populated.")
     }
+
+    if !strings.Contains(err.Error(), "DummyError") || !strings.Contains(err.Error(), "DummyRemoveError") {
+        t.Errorf("The error should report both the failure and the failed removal.  Received %s", err)
+    }
 }
 
-func Test_NewLocalstack_GetLocalstackReturnsResult_RetryFailsOnSecondtService(t *testing.T) {
+func Test_NewLocalstack_ContextCancelled_RemovesContainer(t *testing.T) {
     ctrl := gomock.NewController(t)
 
-    defer ctrl.Finish()	
+    defer ctrl.Finish()
 
     sqs, _ := NewLocalstackService("sqs")
-    s3, _ := NewLocalstackService("s3")
     services := &LocalstackServiceCollection {
         *sqs,
-        *s3,
     }
-    m, _ := getLocalstack_Found(services, ctrl)
+    m := getLocalstack_Empty(services, ctrl)
+    ctx, cancel := context.WithCancel(context.Background())
 
     m.
     EXPECT().
-    RunWithOptions(gomock.Any()).
-    Times(0)
-
-    gomock.InOrder(
-        m.
-        EXPECT().
-        Retry(gomock.Any()).
-        Return(nil),
-        m.
-        EXPECT().
-        Retry(gomock.Any()).
-        Return(errors.New("DummyError")),
-    )
-
-    result, err := newLocalstack(services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
+    RunWithOptions(gomock.Any(), gomock.Any()).
+    Times(1).
+    Return(&dockertest.Resource { Container: &docker.Container { ID: "DummyID" } }, nil)
 
-    if result != nil {
-        log.Fatal("We were expecting the returned container to be nil.")
-    }
+    m.
+    EXPECT().
+    Retry(gomock.Any(), gomock.Any()).
+    Times(1).
+    DoAndReturn(func(ctx context.Context, op func() error) error {
+        cancel()
+        return ctx.Err()
+    })
 
-    if err == nil {
-        log.Fatal("We were expecting the returned error to be populated.")
+    m.
+    EXPECT().
+    RemoveContainer(gomock.Any(), gomock.Any()).
+    Times(1).
+    DoAndReturn(func(ctx context.Context, opts docker.RemoveContainerOptions) error {
+        if opts.ID != "DummyID" {
+            t.Errorf("The wrong container was removed: %s", opts.ID)
+        }
+        if _, ok := ctx.Deadline(); !ok {
+            t.Error("The container should be removed with a context that has a deadline.")
+        }
+        return ctx.Err()
+    })
+
+    if _, err := newLocalstack(ctx, services, m, testOptions...); err == nil {
+        t.Error("We were expecting the returned error to be populated.")
     }
 }
 
@@ -458,17 +478,17 @@ func Test_NewLocalstack_StartFreshContainer_RunWithOptionsReturnsError(t *testin
 
     m.
     EXPECT().
-    RunWithOptions(gomock.Any()).
+    RunWithOptions(gomock.Any(), gomock.Any()).
     Times(1).
     Return(nil, errors.New("Dummy Error"))
 
     m.
     EXPECT().
-    Retry(gomock.Any()).
+    Retry(gomock.Any(), gomock.Any()).
     Times(0).
     Return(nil)
 
-    result, err := newLocalstack(services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
+    result, err := newLocalstack(context.Background(), services, m, testOptions...)
 
     if result != nil {
         log.Fatal("We were expecting the returned container to be nil.")
@@ -495,17 +515,17 @@ func Test_NewLocalstack_StartFreshContainer_RunWithOptions(t *testing.T) {
 
     m.
     EXPECT().
-    RunWithOptions(gomock.Any()).
+    RunWithOptions(gomock.Any(), gomock.Any()).
     Times(1).
     Return(resource, nil)
 
     m.
     EXPECT().
-    Retry(gomock.Any()).
-    Times(2).
+    Retry(gomock.Any(), gomock.Any()).
+    Times(1).
     Return(nil)
 
-    result, err := newLocalstack(services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
+    result, err := newLocalstack(context.Background(), services, m, testOptions...)
 
     if err != nil {
         log.Fatal("We were expecting the returned error to be nil.")
@@ -524,6 +544,138 @@ func Test_NewLocalstack_StartFreshContainer_RunWithOptions(t *testing.T) {
     }
 }
 
+func Test_NewLocalstack_StartFreshContainer_WithOptions(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()	
+
+    sqs, _ := NewLocalstackService("sqs")
+    services := &LocalstackServiceCollection {
+        *sqs,
+    }
+    m := getLocalstack_Empty(services, ctrl)
+    resource := &dockertest.Resource{ }
+    hostConfig := func(config *docker.HostConfig) { config.Memory = 1024 }
+
+    m.
+    EXPECT().
+    RunWithOptions(gomock.Any(), gomock.Any(), gomock.Any()).
+    Times(1).
+    Do(func(ctx context.Context, opts *dockertest.RunOptions, hcOpts ...func(*docker.HostConfig)) {
+        if len(opts.Env) != 2 || opts.Env[1] != "DEBUG=1" {
+            t.Errorf("The environment was not passed through.  Received %v", opts.Env)
+        }
+        if opts.Labels["team"] != "integration" {
+            t.Errorf("The labels were not passed through.  Received %v", opts.Labels)
+        }
+        if len(opts.Mounts) != 1 || opts.Mounts[0] != "/tmp:/data" {
+            t.Errorf("The mounts were not passed through.  Received %v", opts.Mounts)
+        }
+        if len(hcOpts) != 1 {
+            t.Errorf("The host config was not passed through.")
+        }
+    }).
+    Return(resource, nil)
+
+    m.
+    EXPECT().
+    Retry(gomock.Any(), gomock.Any()).
+    Times(1).
+    Do(func(ctx context.Context, op func() error) {
+        deadline, ok := ctx.Deadline()
+        if !ok || time.Until(deadline) > time.Second {
+            t.Error("The readiness timeout was not applied.")
+        }
+    }).
+    Return(nil)
+
+    result, err := newLocalstack(context.Background(), services, m, append(testOptions,
+        WithEnv("DEBUG", "1"),
+        WithLabels(map[string]string { "team": "integration" }),
+        WithMounts("/tmp:/data"),
+        WithHostConfig(hostConfig),
+        WithReadinessTimeout(time.Second))...)
+
+    if err != nil {
+        t.Errorf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if result == nil || result.Resource != resource {
+        t.Error("The returned resource is not what is expected.")
+    }
+}
+
+func Test_NewLocalstack_PullAlways(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()	
+
+    sqs, _ := NewLocalstackService("sqs")
+    services := &LocalstackServiceCollection {
+        *sqs,
+    }
+    m := getLocalstack_Empty(services, ctrl)
+
+    gomock.InOrder(
+        m.
+        EXPECT().
+        PullImage(gomock.Any(), docker.PullImageOptions { Repository: Localstack_Repository, Tag: Localstack_Tag }, gomock.Any()).
+        Return(nil),
+        m.
+        EXPECT().
+        RunWithOptions(gomock.Any(), gomock.Any()).
+        Return(&dockertest.Resource{ }, nil),
+        m.
+        EXPECT().
+        Retry(gomock.Any(), gomock.Any()).
+        Return(nil),
+    )
+
+    _, err := newLocalstack(context.Background(), services, m, append(testOptions, WithPullPolicy(PullAlways))...)
+
+    if err != nil {
+        t.Errorf("We were expecting the returned error to be nil: %s", err)
+    }
+}
+
+func Test_NewLocalstack_PullNever_ImageMissing(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()	
+
+    sqs, _ := NewLocalstackService("sqs")
+    services := &LocalstackServiceCollection {
+        *sqs,
+    }
+    m := getLocalstack_Empty(services, ctrl)
+
+    m.
+    EXPECT().
+    InspectImage(gomock.Any(), fmt.Sprintf("%s:%s", Localstack_Repository, Localstack_Tag)).
+    Times(1).
+    Return(nil, docker.ErrNoSuchImage)
+
+    m.
+    EXPECT().
+    PullImage(gomock.Any(), gomock.Any(), gomock.Any()).
+    Times(0)
+
+    m.
+    EXPECT().
+    RunWithOptions(gomock.Any(), gomock.Any()).
+    Times(0)
+
+    result, err := newLocalstack(context.Background(), services, m, append(testOptions, WithPullPolicy(PullNever))...)
+
+    if result != nil {
+        t.Error("We were expecting the returned container to be nil.")
+    }
+
+    if err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+}
+
 func Test_EndpointFor(t *testing.T) {
     ctrl := gomock.NewController(t)
 
@@ -577,16 +729,16 @@ func Test_EndpointFor(t *testing.T) {
 
     m.
     EXPECT().
-    RunWithOptions(gomock.Any()).
+    RunWithOptions(gomock.Any(), gomock.Any()).
     Times(0)
 
     m.
     EXPECT().
-    Retry(gomock.Any()).
-    Times(21).
+    Retry(gomock.Any(), gomock.Any()).
+    Times(1).
     Return(nil)
 
-    result, err := newLocalstack(services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
+    result, err := newLocalstack(context.Background(), services, m, testOptions...)
 
     if err != nil {
         log.Fatal("We were expecting the returned error to be nil.")
@@ -729,16 +881,16 @@ func Test_EndpointFor_OnlyRegisteredServices(t *testing.T) {
 
     m.
     EXPECT().
-    RunWithOptions(gomock.Any()).
+    RunWithOptions(gomock.Any(), gomock.Any()).
     Times(0)
 
     m.
     EXPECT().
-    Retry(gomock.Any()).
-    Times(2).
+    Retry(gomock.Any(), gomock.Any()).
+    Times(1).
     Return(nil)
 
-    result, err := newLocalstack(services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
+    result, err := newLocalstack(context.Background(), services, m, testOptions...)
 
     if err != nil {
         log.Fatal("We were expecting the returned error to be nil.")
@@ -877,16 +1029,16 @@ func Test_CreateAWSSession(t *testing.T) {
 
     m.
     EXPECT().
-    RunWithOptions(gomock.Any()).
+    RunWithOptions(gomock.Any(), gomock.Any()).
     Times(0)
 
     m.
     EXPECT().
-    Retry(gomock.Any()).
-    Times(2).
+    Retry(gomock.Any(), gomock.Any()).
+    Times(1).
     Return(nil)
 
-    result, err := newLocalstack(services, m, Localstack_Name, Localstack_Repository, Localstack_Tag)
+    result, err := newLocalstack(context.Background(), services, m, testOptions...)
 
     if err != nil {
         log.Fatal("We were expecting the returned error to be nil.")
@@ -915,3 +1067,110 @@ func Test_CreateAWSSession(t *testing.T) {
     }
 }
 
+
+func Test_DestroyContext(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()	
+
+    sqs, _ := NewLocalstackService("sqs")
+    services := &LocalstackServiceCollection {
+        *sqs,
+    }
+    m, c := getLocalstack_Found(services, ctrl)
+    c.ID = "DummyID"
+
+    m.
+    EXPECT().
+    Retry(gomock.Any(), gomock.Any()).
+    Times(1).
+    Return(nil)
+
+    m.
+    EXPECT().
+    RemoveContainer(gomock.Any(), docker.RemoveContainerOptions { ID: "DummyID", Force: true, RemoveVolumes: true }).
+    Times(1).
+    Return(nil)
+
+    result, err := newLocalstack(context.Background(), services, m, testOptions...)
+    if err != nil {
+        log.Fatal("We were expecting the returned error to be nil.")
+    }
+
+    if err := result.DestroyContext(context.Background()); err != nil {
+        t.Errorf("We were expecting the returned error to be nil: %s", err)
+    }
+}
+
+func Test_DestroyContext_RemoveContainerReturnsError(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()	
+
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+
+    m.
+    EXPECT().
+    RemoveContainer(gomock.Any(), gomock.Any()).
+    Times(1).
+    Return(context.DeadlineExceeded)
+
+    result := &Localstack {
+        Resource: &dockertest.Resource { Container: &docker.Container { ID: "DummyID" } },
+        wrapper: m,
+    }
+
+    if err := result.DestroyContext(context.Background()); err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+}
+
+func strictLocalstack(opts ...Option) *Localstack {
+    s3, _ := NewLocalstackService("s3")
+    return &Localstack {
+        Resource: &dockertest.Resource { Container: &docker.Container {
+            NetworkSettings: &docker.NetworkSettings {
+                Ports: map[docker.Port][]docker.PortBinding {
+                    "4572/tcp": []docker.PortBinding {docker.PortBinding { HostIP: "1.0.0.0", HostPort: "9572" }},
+                },
+            },
+        }},
+        Services: &LocalstackServiceCollection { *s3 },
+        portMode: PortModeLegacy,
+        options: newLocalstackOptions(opts...),
+    }
+}
+
+func Test_StrictEndpointFor(t *testing.T) {
+    ls := strictLocalstack()
+
+    ep, err := ls.StrictEndpointFor(endpoints.S3ServiceID, "us-east-1")
+    if err != nil || ep.URL != "http://1.0.0.0:9572" {
+        t.Errorf("The return URL was not correct.  Received %s", ep.URL)
+    }
+
+    _, err = ls.StrictEndpointFor(endpoints.SqsServiceID, "us-east-1")
+    if err == nil || !strings.Contains(err.Error(), "sqs") {
+        t.Errorf("The error should name the service that wasn't requested.  Received %v", err)
+    }
+
+    _, err = ls.StrictEndpointFor(endpoints.Ec2ServiceID, "us-east-1")
+    if err == nil || !strings.Contains(err.Error(), "ec2") {
+        t.Errorf("The error should name the service that isn't supported.  Received %v", err)
+    }
+}
+
+func Test_CreateAWSSession_Strict(t *testing.T) {
+    svc := sqs.New(strictLocalstack().CreateAWSSession())
+    _, err := svc.ListQueues(&sqs.ListQueuesInput{ })
+    if err == nil || !strings.Contains(err.Error(), "sqs") {
+        t.Errorf("Requests to a service that wasn't requested should fail.  Received %v", err)
+    }
+}
+
+func Test_CreateAWSSession_EndpointFallback(t *testing.T) {
+    config := strictLocalstack(WithEndpointFallback()).CreateAWSSession().ClientConfig(endpoints.SqsServiceID)
+    if !strings.Contains(config.Endpoint, "amazonaws.com") {
+        t.Errorf("The service should resolve to AWS.  Received %s", config.Endpoint)
+    }
+}
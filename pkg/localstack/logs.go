@@ -0,0 +1,94 @@
+package localstack
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "sync"
+    "testing"
+    "github.com/ory/dockertest/docker"
+)
+
+// StreamLogs follows the stdout and stderr of the Localstack container, writing
+// them to the writer given.  It blocks until the container stops or the context
+// is done, so it is usually run in its own goroutine.  The logs written before
+// StreamLogs was called are included.
+func (ls *Localstack) StreamLogs(ctx context.Context, w io.Writer) error {
+    err := ls.dockerWrapper().Logs(ctx, docker.LogsOptions {
+        Container: ls.Resource.Container.ID,
+        OutputStream: w,
+        ErrorStream: w,
+        Follow: true,
+        Stdout: true,
+        Stderr: true,
+    })
+    if err != nil && ctx.Err() == nil {
+        return errors.New(fmt.Sprintf("Unable to stream logs for container %s: %s", ls.Resource.Container.ID, err))
+    }
+    return nil
+}
+
+// LogToTest streams the logs of the Localstack container into t.Log, one line at
+// a time with the prefix given.  (I.E. ls.LogToTest(t, "localstack: "))
+// The function returned stops streaming and must be called before the test
+// finishes.  (I.E. defer ls.LogToTest(t, "localstack: ")())
+func (ls *Localstack) LogToTest(t testing.TB, prefix string) func() {
+    ctx, cancel := context.WithCancel(context.Background())
+    writer := newLineWriter(func(line string) {
+        t.Log(prefix + line)
+    })
+
+    done := make(chan struct{ })
+    go func() {
+        defer close(done)
+        if err := ls.StreamLogs(ctx, writer); err != nil {
+            t.Log(prefix + err.Error())
+        }
+    }()
+
+    return func() {
+        cancel()
+        <-done
+        writer.Flush()
+    }
+}
+
+// lineWriter is an io.Writer that calls a function for every complete line written.
+type lineWriter struct {
+    mutex sync.Mutex
+    buffer bytes.Buffer
+    line func(string)
+}
+
+func newLineWriter(line func(string)) *lineWriter {
+    return &lineWriter { line: line }
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+    lw.mutex.Lock()
+    defer lw.mutex.Unlock()
+
+    lw.buffer.Write(p)
+    for {
+        index := bytes.IndexByte(lw.buffer.Bytes(), '\n')
+        if index < 0 {
+            break
+        }
+        line := lw.buffer.Next(index + 1)
+        lw.line(string(bytes.TrimRight(line, "\r\n")))
+    }
+    return len(p), nil
+}
+
+// Flush calls the function with whatever is left of an incomplete line.
+func (lw *lineWriter) Flush() {
+    lw.mutex.Lock()
+    defer lw.mutex.Unlock()
+
+    if lw.buffer.Len() > 0 {
+        lw.line(lw.buffer.String())
+        lw.buffer.Reset()
+    }
+}
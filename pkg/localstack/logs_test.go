@@ -0,0 +1,102 @@
+package localstack
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "testing"
+    "github.com/ory/dockertest"
+    "github.com/ory/dockertest/docker"
+    "github.com/mitchelldavis/go_localstack/pkg/mock_localstack"
+    "github.com/golang/mock/gomock"
+)
+
+// recordingT captures what is logged to a test.
+type recordingT struct {
+    testing.TB
+    lines []string
+}
+
+func (r *recordingT) Log(args ...interface{}) {
+    r.lines = append(r.lines, fmt.Sprint(args...))
+}
+
+func logsLocalstack(m *mock_localstack.MockDockerWrapper) *Localstack {
+    return &Localstack {
+        Resource: &dockertest.Resource { Container: &docker.Container { ID: "DummyID" } },
+        wrapper: m,
+    }
+}
+
+func Test_StreamLogs(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+    m.
+    EXPECT().
+    Logs(gomock.Any(), gomock.Any()).
+    Times(1).
+    DoAndReturn(func(ctx context.Context, opts docker.LogsOptions) error {
+        if !opts.Follow || !opts.Stdout || !opts.Stderr {
+            t.Errorf("The logs should be followed on stdout and stderr.  Received %+v", opts)
+        }
+        opts.OutputStream.Write([]byte("Starting mock services\n"))
+        opts.ErrorStream.Write([]byte("Ready.\n"))
+        return nil
+    })
+
+    buffer := new(bytes.Buffer)
+    if err := logsLocalstack(m).StreamLogs(context.Background(), buffer); err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if buffer.String() != "Starting mock services\nReady.\n" {
+        t.Errorf("The logs should be written to the writer.  Received %q", buffer.String())
+    }
+}
+
+func Test_StreamLogs_ReturnsError(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+    m.
+    EXPECT().
+    Logs(gomock.Any(), gomock.Any()).
+    Return(errors.New("Dummy Error"))
+
+    if err := logsLocalstack(m).StreamLogs(context.Background(), new(bytes.Buffer)); err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+}
+
+func Test_LogToTest(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+    m.
+    EXPECT().
+    Logs(gomock.Any(), gomock.Any()).
+    DoAndReturn(func(ctx context.Context, opts docker.LogsOptions) error {
+        opts.OutputStream.Write([]byte("first\nsec"))
+        opts.OutputStream.Write([]byte("ond\r\nthird"))
+        <-ctx.Done()
+        return ctx.Err()
+    })
+
+    recorder := &recordingT { TB: t }
+    stop := logsLocalstack(m).LogToTest(recorder, "localstack: ")
+    stop()
+
+    expected := "localstack: first|localstack: second|localstack: third"
+    if strings.Join(recorder.lines, "|") != expected {
+        t.Errorf("Each line should be logged with the prefix.  Received %v", recorder.lines)
+    }
+}
@@ -0,0 +1,257 @@
+package localstack
+
+import (
+    "time"
+    "github.com/ory/dockertest/docker"
+)
+
+// Localstack_ReadinessTimeout is the default amount of time to wait for
+// Localstack to report that it is ready.
+const Localstack_ReadinessTimeout time.Duration = time.Minute * 5
+
+// PullPolicy controls when the Localstack image is pulled from its registry.
+type PullPolicy int
+
+const (
+    // PullIfMissing only pulls the image when it isn't available locally.
+    // This is the default.
+    PullIfMissing PullPolicy = iota
+    // PullAlways pulls the image every time a new container is started.
+    PullAlways
+    // PullNever never pulls the image.  Starting a container will fail if
+    // the image isn't available locally.
+    PullNever
+)
+
+// Option configures how a Localstack container is created.
+// See NewLocalstackWithOptions.
+type Option func(*localstackOptions)
+
+type localstackOptions struct {
+    name string
+    repository string
+    tag string
+    env []string
+    labels map[string]string
+    mounts []string
+    hostConfig []func(*docker.HostConfig)
+    pullPolicy PullPolicy
+    readinessTimeout time.Duration
+    waitStrategy WaitStrategy
+    reusePolicy ReusePolicy
+    shared bool
+    reapAge time.Duration
+    portMode PortMode
+    dataDir string
+    snapshot string
+    persist bool
+    endpointFallback bool
+    tls bool
+    faultProxy *FaultProxy
+    recorders []*Recorder
+}
+
+func newLocalstackOptions(opts ...Option) *localstackOptions {
+    options := &localstackOptions {
+        repository: Localstack_Repository,
+        tag: "latest",
+        labels: map[string]string{},
+        pullPolicy: PullIfMissing,
+        readinessTimeout: Localstack_ReadinessTimeout,
+    }
+    for _, opt := range opts {
+        opt(options)
+    }
+    return options
+}
+
+// WithName sets the name of the docker container.  When a container with
+// the same name and image already exists, it is reused instead of starting
+// a new one.  (See WithReusePolicy)
+func WithName(name string) Option {
+    return func(o *localstackOptions) {
+        o.name = name
+    }
+}
+
+// WithRepository sets the docker repository of the Localstack image.
+// NOTE:  The Docker image used should be a Localstack image.  The behavior
+// is unknown otherwise.
+func WithRepository(repository string) Option {
+    return func(o *localstackOptions) {
+        o.repository = repository
+    }
+}
+
+// WithTag sets the tag of the Localstack image.
+func WithTag(tag string) Option {
+    return func(o *localstackOptions) {
+        o.tag = tag
+    }
+}
+
+// WithEnv adds an environment variable to the container.
+// (I.E. WithEnv("DEBUG", "1"))
+func WithEnv(key, value string) Option {
+    return func(o *localstackOptions) {
+        o.env = append(o.env, key + "=" + value)
+    }
+}
+
+// WithLabels adds docker labels to the container.
+func WithLabels(labels map[string]string) Option {
+    return func(o *localstackOptions) {
+        for key, value := range labels {
+            o.labels[key] = value
+        }
+    }
+}
+
+// WithMounts adds bind mounts to the container.  Each mount has
+// the format <src>:<dst>.
+func WithMounts(mounts ...string) Option {
+    return func(o *localstackOptions) {
+        o.mounts = append(o.mounts, mounts...)
+    }
+}
+
+// WithHostConfig registers a function that can modify the
+// docker.HostConfig before the container is created.
+// (https://godoc.org/github.com/ory/dockertest/docker#HostConfig)
+func WithHostConfig(config func(*docker.HostConfig)) Option {
+    return func(o *localstackOptions) {
+        o.hostConfig = append(o.hostConfig, config)
+    }
+}
+
+// WithPullPolicy sets when the Localstack image is pulled.
+func WithPullPolicy(policy PullPolicy) Option {
+    return func(o *localstackOptions) {
+        o.pullPolicy = policy
+    }
+}
+
+// WithReadinessTimeout sets how long to wait for Localstack to become ready.
+// The default is Localstack_ReadinessTimeout.
+func WithReadinessTimeout(timeout time.Duration) Option {
+    return func(o *localstackOptions) {
+        o.readinessTimeout = timeout
+    }
+}
+
+// WithWaitStrategy sets how to decide when Localstack is ready.  By default
+// WaitForHealth is used with the edge port and the log is checked for the
+// Localstack_ReadyPattern with the legacy ports.  (See WithPortMode)
+func WithWaitStrategy(strategy WaitStrategy) Option {
+    return func(o *localstackOptions) {
+        o.waitStrategy = strategy
+    }
+}
+
+// WithReusePolicy sets what to do when an existing container is found
+// whose services don't match the requested services.  The default is ReuseFail.
+func WithReusePolicy(policy ReusePolicy) Option {
+    return func(o *localstackOptions) {
+        o.reusePolicy = policy
+    }
+}
+
+// WithShared shares a single container between every holder that requests
+// the same image and services, even across test processes.  (I.E. the packages
+// run by `go test ./...`)  The container is only cleaned up when the last
+// holder calls Destroy.  Unless WithName is given, the container is named
+// after a hash of the image, the services and the options that change how the
+// container is created, (I.E. WithEnv, WithMounts, WithPortMode, WithDataDir,
+// FromSnapshot, WithPersistence and WithTLS) so holders only share a container
+// when they ask for the same one.  WithHostConfig isn't part of the hash.
+// The holders are recorded in a file in the temp directory of the host,
+// guarded by a lock file, rather than in docker labels, because labels can't
+// be changed once the container is created.
+func WithShared() Option {
+    return func(o *localstackOptions) {
+        o.shared = true
+    }
+}
+
+// WithReaper removes containers left behind by earlier sessions that are older
+// than maxAge before starting Localstack.  (See Reap)
+func WithReaper(maxAge time.Duration) Option {
+    return func(o *localstackOptions) {
+        o.reapAge = maxAge
+    }
+}
+
+// WithPortMode sets which container ports the services are reached on.
+// The default is PortModeAuto.
+func WithPortMode(mode PortMode) Option {
+    return func(o *localstackOptions) {
+        o.portMode = mode
+    }
+}
+
+// WithDataDir persists the state of Localstack into the host directory given.
+// The directory is mounted into the container at Localstack_DataDir and
+// DATA_DIR is set so Localstack records its state there.  A container started
+// with a data directory can be snapshotted.  (See Localstack.Snapshot)
+func WithDataDir(dir string) Option {
+    return func(o *localstackOptions) {
+        o.dataDir = dir
+    }
+}
+
+// FromSnapshot starts Localstack with the state saved by Localstack.Snapshot.
+// The snapshot is copied into a temporary data directory, so it is left untouched
+// and can be reused by many containers.  The temporary directory is removed
+// when the container is destroyed.
+func FromSnapshot(dir string) Option {
+    return func(o *localstackOptions) {
+        o.snapshot = dir
+    }
+}
+
+// WithPersistence persists the state of Localstack inside the container
+// filesystem at Localstack_DataDir, so it is captured by Localstack.CommitImage.
+func WithPersistence() Option {
+    return func(o *localstackOptions) {
+        o.persist = true
+    }
+}
+
+// WithEndpointFallback lets sessions created by Localstack.CreateAWSSession
+// resolve services that weren't requested from Localstack to AWS, instead of
+// failing.  (See Localstack.StrictEndpointFor)
+func WithEndpointFallback() Option {
+    return func(o *localstackOptions) {
+        o.endpointFallback = true
+    }
+}
+
+// WithTLS serves Localstack over HTTPS.  A CA and a certificate signed by it are
+// generated and the certificate is given to Localstack, which is started with
+// USE_SSL.  Endpoints use https:// and the sessions created by the Localstack
+// trust the CA.  (See Localstack.HTTPClient and Localstack.CACertificate)
+func WithTLS() Option {
+    return func(o *localstackOptions) {
+        o.tls = true
+    }
+}
+
+// WithFaultProxy routes the SDK traffic to Localstack through the proxy given,
+// which injects the faults added to it.  (See FaultProxy)  The endpoints
+// resolve to the proxy, which is served over HTTP on a local port, and the
+// proxy is stopped when the Localstack is destroyed.
+func WithFaultProxy(proxy *FaultProxy) Option {
+    return func(o *localstackOptions) {
+        o.faultProxy = proxy
+    }
+}
+
+// WithRecorder installs the recorder given on every session the Localstack
+// creates, including the sessions of CreateAWSSession and of the client
+// accessors (I.E. Localstack.S3), so their operations are captured.
+// (See Recorder)
+func WithRecorder(rec *Recorder) Option {
+    return func(o *localstackOptions) {
+        o.recorders = append(o.recorders, rec)
+    }
+}
@@ -0,0 +1,153 @@
+package localstack
+
+import (
+    "context"
+    "fmt"
+    "regexp"
+    "strconv"
+    "github.com/aws/aws-sdk-go/aws/endpoints"
+)
+
+// PortMode decides which container ports the services are reached on.
+type PortMode int
+
+const (
+    // PortModeAuto detects the port mode from the version of Localstack.
+    // This is the default.
+    PortModeAuto PortMode = iota
+    // PortModeLegacy reaches each service on its own port.  (See LocalstackService.Port)
+    // Versions of Localstack before 0.11.0 only support this mode.
+    PortModeLegacy
+    // PortModeEdge reaches every service through the edge port.  (See Localstack_EdgePort)
+    PortModeEdge
+)
+
+// String returns the name of the port mode.
+func (mode PortMode) String() string {
+    switch mode {
+    case PortModeLegacy:
+        return "legacy"
+    case PortModeEdge:
+        return "edge"
+    default:
+        return "auto"
+    }
+}
+
+// endpointServices maps the AWS SDK service ids to the Localstack service names.
+var endpointServices = map[string]string {
+    endpoints.ApigatewayServiceID: "apigateway",
+    endpoints.KinesisServiceID: "kinesis",
+    endpoints.DynamodbServiceID: "dynamodb",
+    endpoints.StreamsDynamodbServiceID: "dynamodbstreams",
+    endpoints.EsServiceID: "es",
+    endpoints.S3ServiceID: "s3",
+    endpoints.FirehoseServiceID: "firehose",
+    endpoints.LambdaServiceID: "lambda",
+    endpoints.SnsServiceID: "sns",
+    endpoints.SqsServiceID: "sqs",
+    endpoints.RedshiftServiceID: "redshift",
+    endpoints.EmailServiceID: "ses",
+    endpoints.Route53ServiceID: "route53",
+    endpoints.CloudformationServiceID: "cloudformation",
+    endpoints.MonitoringServiceID: "cloudwatch",
+    endpoints.SsmServiceID: "ssm",
+    endpoints.SecretsmanagerServiceID: "secretsmanager",
+    endpoints.StatesServiceID: "stepfunctions",
+    endpoints.LogsServiceID: "logs",
+    endpoints.StsServiceID: "sts",
+    endpoints.IamServiceID: "iam",
+}
+
+// Localstack_VersionLabels are the image labels checked for the version of Localstack.
+var Localstack_VersionLabels = []string {
+    "org.opencontainers.image.version",
+    "version",
+}
+
+var versionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)`)
+
+// versionPortMode returns the port mode supported by a version of Localstack.
+// ok is false when the version can't be parsed.
+func versionPortMode(version string) (mode PortMode, ok bool) {
+    match := versionPattern.FindStringSubmatch(version)
+    if match == nil {
+        return PortModeAuto, false
+    }
+    major, _ := strconv.Atoi(match[1])
+    minor, _ := strconv.Atoi(match[2])
+    if major > 0 || minor >= 11 {
+        return PortModeEdge, true
+    }
+    return PortModeLegacy, true
+}
+
+// tagPortMode returns the port mode supported by an image tag.
+func tagPortMode(tag string) (mode PortMode, ok bool) {
+    switch tag {
+    case "", "latest", "stable":
+        return PortModeEdge, true
+    default:
+        return versionPortMode(tag)
+    }
+}
+
+// detectPortMode detects the port mode of a running Localstack from its image
+// labels, its health endpoint, and finally the image tag.
+func (ls *Localstack) detectPortMode(ctx context.Context, tag string) PortMode {
+    if ls.Resource.Container != nil && ls.Resource.Container.Config != nil {
+        for _, label := range Localstack_VersionLabels {
+            if mode, ok := versionPortMode(ls.Resource.Container.Config.Labels[label]); ok {
+                return mode
+            }
+        }
+    }
+
+    // The health endpoint is only served on the edge port.
+    if report, err := ls.Health(ctx); err == nil {
+        if mode, ok := versionPortMode(report.Version); ok {
+            return mode
+        }
+        return PortModeEdge
+    }
+
+    if mode, ok := tagPortMode(tag); ok {
+        return mode
+    }
+    return PortModeLegacy
+}
+
+// PortMode returns the port mode the services are reached with.
+func (ls *Localstack) PortMode() PortMode {
+    if ls.portMode == PortModeAuto {
+        return PortModeLegacy
+    }
+    return ls.portMode
+}
+
+// servicePort returns the container port (I.E. "4566/tcp") of the named service.
+func (ls *Localstack) servicePort(name string) string {
+    if ls.PortMode() == PortModeEdge {
+        return Localstack_EdgePort
+    }
+    for _, service := range *ls.Services {
+        if service.Name == name {
+            return service.GetPortProtocol()
+        }
+    }
+    return ""
+}
+
+// serviceURL returns the URL the named service is reached on from the host.
+// With WithFaultProxy, it is the URL of the proxy.
+func (ls *Localstack) serviceURL(name string) string {
+    if proxy := ls.faultProxy(); proxy != nil && proxy.URL() != "" {
+        return proxy.URL()
+    }
+    return ls.directURL(name)
+}
+
+// directURL returns the URL the named service is reached on in the container.
+func (ls *Localstack) directURL(name string) string {
+    return fmt.Sprintf("%s://%s", ls.scheme(), ls.Resource.GetHostPort(ls.servicePort(name)))
+}
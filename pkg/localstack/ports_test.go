@@ -0,0 +1,141 @@
+package localstack
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "github.com/ory/dockertest"
+    "github.com/ory/dockertest/docker"
+    "github.com/golang/mock/gomock"
+    "github.com/aws/aws-sdk-go/aws/endpoints"
+)
+
+func Test_versionPortMode(t *testing.T) {
+    cases := map[string]PortMode {
+        "0.9.1": PortModeLegacy,
+        "0.10.9": PortModeLegacy,
+        "0.11.0": PortModeEdge,
+        "v0.12.2": PortModeEdge,
+        "1.4.0": PortModeEdge,
+        "3.0.0.dev": PortModeEdge,
+    }
+    for version, expected := range cases {
+        if mode, ok := versionPortMode(version); !ok || mode != expected {
+            t.Errorf("The version %s should use the %s port mode.  Received %s", version, expected, mode)
+        }
+    }
+
+    if _, ok := versionPortMode("garbage"); ok {
+        t.Error("An unknown version should not be parsed.")
+    }
+}
+
+func Test_tagPortMode(t *testing.T) {
+    if mode, ok := tagPortMode("latest"); !ok || mode != PortModeEdge {
+        t.Error("The latest tag should use the edge port mode.")
+    }
+
+    if mode, ok := tagPortMode(Localstack_Tag); !ok || mode != PortModeLegacy {
+        t.Error("The last tested tag should use the legacy port mode.")
+    }
+
+    if _, ok := tagPortMode("internal-build"); ok {
+        t.Error("An unknown tag should not be parsed.")
+    }
+}
+
+func Test_detectPortMode_Labels(t *testing.T) {
+    ls := &Localstack { Resource: &dockertest.Resource { Container: &docker.Container {
+        Config: &docker.Config { Labels: map[string]string { "org.opencontainers.image.version": "0.12.0" } },
+    }}}
+
+    if mode := ls.detectPortMode(context.Background(), Localstack_Tag); mode != PortModeEdge {
+        t.Errorf("The version label should be preferred over the tag.  Received %s", mode)
+    }
+}
+
+func Test_detectPortMode_Health(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(`{"services": {}}`))
+    }))
+    defer server.Close()
+
+    ls := &Localstack { Resource: &dockertest.Resource { Container: healthContainer(server) } }
+
+    if mode := ls.detectPortMode(context.Background(), "internal-build"); mode != PortModeEdge {
+        t.Errorf("A reachable health endpoint means the edge port is used.  Received %s", mode)
+    }
+}
+
+func Test_detectPortMode_Unknown(t *testing.T) {
+    ls := &Localstack { Resource: &dockertest.Resource { Container: &docker.Container { } } }
+
+    if mode := ls.detectPortMode(context.Background(), "internal-build"); mode != PortModeLegacy {
+        t.Errorf("The legacy port mode should be used when nothing is known.  Received %s", mode)
+    }
+}
+
+func Test_EndpointFor_Edge(t *testing.T) {
+    sqs, _ := NewLocalstackService("sqs")
+    s3, _ := NewLocalstackService("s3")
+    ls := Localstack {
+        Resource: &dockertest.Resource { Container: &docker.Container {
+            NetworkSettings: &docker.NetworkSettings {
+                Ports: map[docker.Port][]docker.PortBinding {
+                    "4566/tcp": []docker.PortBinding {docker.PortBinding { HostIP: "1.0.0.0", HostPort: "9566" }},
+                    "4576/tcp": []docker.PortBinding {docker.PortBinding { HostIP: "1.0.0.0", HostPort: "9576" }},
+                },
+            },
+        }},
+        Services: &LocalstackServiceCollection { *sqs, *s3 },
+        portMode: PortModeEdge,
+    }
+
+    for _, service := range []string { endpoints.SqsServiceID, endpoints.S3ServiceID } {
+        ep, err := ls.EndpointFor(service, "us-west-2")
+        if err != nil || ep.URL != "http://1.0.0.0:9566" {
+            t.Errorf("The return URL was not correct.  Received %s", ep.URL)
+        }
+    }
+}
+
+func Test_NewLocalstack_EdgeServicesEnv(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    sqs, _ := NewLocalstackService("sqs")
+    s3, _ := NewLocalstackService("s3")
+    services := &LocalstackServiceCollection {
+        *sqs,
+        *s3,
+    }
+    m := getLocalstack_Empty(services, ctrl)
+
+    m.
+    EXPECT().
+    RunWithOptions(gomock.Any(), gomock.Any()).
+    Times(1).
+    Do(func(ctx context.Context, opts *dockertest.RunOptions, hcOpts ...func(*docker.HostConfig)) {
+        if opts.Env[0] != "SERVICES=sqs,s3" {
+            t.Errorf("The services should not include ports with the edge port.  Received %s", opts.Env[0])
+        }
+    }).
+    Return(&dockertest.Resource{ }, nil)
+
+    m.
+    EXPECT().
+    Retry(gomock.Any(), gomock.Any()).
+    Times(1).
+    Return(nil)
+
+    result, err := newLocalstack(context.Background(), services, m, WithName(Localstack_Name), WithTag("latest"))
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if result.PortMode() != PortModeEdge {
+        t.Errorf("The latest tag should use the edge port mode.  Received %s", result.PortMode())
+    }
+}
@@ -0,0 +1,16 @@
+// +build !windows
+
+package localstack
+
+import (
+    "syscall"
+)
+
+// processAlive returns whether a process with the id given is running.
+func processAlive(pid int) bool {
+    if pid <= 0 {
+        return false
+    }
+    err := syscall.Kill(pid, syscall.Signal(0))
+    return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,18 @@
+// +build windows
+
+package localstack
+
+import (
+    "os"
+)
+
+// processAlive returns whether a process with the id given is running.
+// On windows, finding a process fails when it doesn't exist.
+func processAlive(pid int) bool {
+    process, err := os.FindProcess(pid)
+    if err != nil {
+        return false
+    }
+    process.Release()
+    return true
+}
@@ -0,0 +1,152 @@
+package localstack
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "os"
+    "os/user"
+    "strconv"
+    "strings"
+    "time"
+    "github.com/ory/dockertest/docker"
+)
+
+const (
+    // Localstack_OwnerLabel is the docker label that holds the user and host
+    // that started a container.
+    Localstack_OwnerLabel string = "go_localstack.owner"
+    // Localstack_SessionLabel is the docker label that holds the id of the
+    // process session that started a container.
+    Localstack_SessionLabel string = "go_localstack.session"
+    // Localstack_CreatedLabel is the docker label that holds the time (RFC3339)
+    // a container was started.
+    Localstack_CreatedLabel string = "go_localstack.created"
+)
+
+// Owner is the value of the owner label given to every container started by
+// this process.  Only containers with the same owner are reaped.
+var Owner = defaultOwner()
+
+// Session is the value of the session label given to every container started
+// by this process.  Containers from the current session are never reaped.
+var Session = newSession()
+
+func defaultOwner() string {
+    name := "unknown"
+    if current, err := user.Current(); err == nil {
+        name = current.Username
+    }
+    host, err := os.Hostname()
+    if err != nil {
+        host = "unknown"
+    }
+    return fmt.Sprintf("%s@%s", name, host)
+}
+
+func newSession() string {
+    random := make([]byte, 8)
+    rand.Read(random)
+    return fmt.Sprintf("%d-%s", os.Getpid(), hex.EncodeToString(random))
+}
+
+// sessionAlive returns whether the process of the session given is still running.
+// Sessions start with the id of their process.  (See newSession)
+func sessionAlive(session string) bool {
+    if session == Session {
+        return true
+    }
+    pid, err := strconv.Atoi(strings.Split(session, "-")[0])
+    return err == nil && processAlive(pid)
+}
+
+// sessionLabels are the labels given to every container.
+func sessionLabels(now time.Time) map[string]string {
+    return map[string]string {
+        Localstack_OwnerLabel: Owner,
+        Localstack_SessionLabel: Session,
+        Localstack_CreatedLabel: now.UTC().Format(time.RFC3339),
+    }
+}
+
+// Reap removes containers left behind by earlier sessions (I.E. when a test binary
+// was killed before Destroy was called) that are older than maxAge.  Containers
+// of sessions whose process is still running are left alone.  It returns
+// the number of containers that were removed.
+func Reap(maxAge time.Duration) (int, error) {
+    return ReapContext(context.Background(), maxAge)
+}
+
+// ReapContext is Reap with a context that can be used to cancel the call.
+func ReapContext(ctx context.Context, maxAge time.Duration) (int, error) {
+    return reap(ctx, &_DockerWrapper{ }, maxAge, time.Now())
+}
+
+// StartReaper calls Reap every interval until the context is done.  Errors are
+// ignored and the call is simply tried again at the next interval.
+func StartReaper(ctx context.Context, interval, maxAge time.Duration) {
+    go func() {
+        for {
+            ReapContext(ctx, maxAge)
+            select {
+            case <-ctx.Done():
+                return
+            case <-time.After(interval):
+            }
+        }
+    }()
+}
+
+func reap(ctx context.Context, wrapper DockerWrapper, maxAge time.Duration, now time.Time) (int, error) {
+    containers, err := wrapper.ListContainers(ctx, docker.ListContainersOptions {
+        All: true,
+        Filters: map[string][]string {
+            "label": []string { fmt.Sprintf("%s=%s", Localstack_OwnerLabel, Owner) },
+        },
+    })
+    if err != nil {
+        return 0, errors.New(fmt.Sprintf("Unable to retrieve docker containers: %s", err))
+    }
+
+    var failures []string
+    reaped := 0
+    for _, c := range containers {
+        // Containers of sessions that are still running are in use.
+        if sessionAlive(c.Labels[Localstack_SessionLabel]) {
+            continue
+        }
+
+        created := time.Unix(c.Created, 0)
+        if label, err := time.Parse(time.RFC3339, c.Labels[Localstack_CreatedLabel]); err == nil {
+            created = label
+        }
+        if now.Sub(created) < maxAge {
+            continue
+        }
+
+        // A shared container is still in use while it has holders.
+        if key := c.Labels[Localstack_SharedLabel]; key != "" {
+            holders, err := (&sharedHolder { key: key, dir: os.TempDir() }).holders()
+            if err != nil || len(holders) > 0 {
+                continue
+            }
+        }
+
+        if err := wrapper.RemoveContainer(ctx, docker.RemoveContainerOptions {
+            ID: c.ID,
+            Force: true,
+            RemoveVolumes: true,
+        }); err != nil {
+            failures = append(failures, fmt.Sprintf("%s: %s", c.ID, err))
+            continue
+        }
+        reaped++
+    }
+
+    if len(failures) > 0 {
+        return reaped, errors.New(fmt.Sprintf("Unable to remove containers: %s", strings.Join(failures, "; ")))
+    }
+    return reaped, nil
+}
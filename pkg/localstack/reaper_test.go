@@ -0,0 +1,152 @@
+package localstack
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "testing"
+    "time"
+    "github.com/ory/dockertest"
+    "github.com/ory/dockertest/docker"
+    "github.com/mitchelldavis/go_localstack/pkg/mock_localstack"
+    "github.com/golang/mock/gomock"
+)
+
+func reaperContainer(id, session string, created time.Time) docker.APIContainers {
+    return docker.APIContainers {
+        ID: id,
+        Labels: map[string]string {
+            Localstack_OwnerLabel: Owner,
+            Localstack_SessionLabel: session,
+            Localstack_CreatedLabel: created.UTC().Format(time.RFC3339),
+        },
+    }
+}
+
+func Test_reap(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    dir, _ := ioutil.TempDir("", "go_localstack")
+    defer os.RemoveAll(dir)
+    tmpdir := os.Getenv("TMPDIR")
+    os.Setenv("TMPDIR", dir)
+    defer os.Setenv("TMPDIR", tmpdir)
+
+    now := time.Now()
+    held := reaperContainer("Held", "OldSession", now.Add(-time.Hour))
+    held.Labels[Localstack_SharedLabel] = "DummyKey"
+    holder := &sharedHolder { key: "DummyKey", id: "1-0000", dir: dir }
+    holder.writeHolders([]string { Session })
+
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+
+    m.
+    EXPECT().
+    ListContainers(gomock.Any(), gomock.Any()).
+    Times(1).
+    Do(func(ctx context.Context, opts docker.ListContainersOptions) {
+        if opts.Filters["label"][0] != Localstack_OwnerLabel + "=" + Owner {
+            t.Errorf("Only containers from this owner should be listed.  Received %v", opts.Filters)
+        }
+    }).
+    Return([]docker.APIContainers {
+        reaperContainer("Current", Session, now.Add(-time.Hour)),
+        reaperContainer("Running", fmt.Sprintf("%d-0000", os.Getppid()), now.Add(-time.Hour)),
+        reaperContainer("Young", "OldSession", now.Add(-time.Minute)),
+        reaperContainer("Old", "OldSession", now.Add(-time.Hour)),
+        held,
+    }, nil)
+
+    m.
+    EXPECT().
+    RemoveContainer(gomock.Any(), docker.RemoveContainerOptions { ID: "Old", Force: true, RemoveVolumes: true }).
+    Times(1).
+    Return(nil)
+
+    reaped, err := reap(context.Background(), m, time.Minute * 30, now)
+    if err != nil {
+        t.Errorf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if reaped != 1 {
+        t.Errorf("Only one container should have been reaped.  Reaped %d", reaped)
+    }
+}
+
+func Test_reap_RemoveContainerReturnsError(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    now := time.Now()
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+
+    m.
+    EXPECT().
+    ListContainers(gomock.Any(), gomock.Any()).
+    Times(1).
+    Return([]docker.APIContainers {
+        reaperContainer("First", "OldSession", now.Add(-time.Hour)),
+        reaperContainer("Second", "OldSession", now.Add(-time.Hour)),
+    }, nil)
+
+    gomock.InOrder(
+        m.
+        EXPECT().
+        RemoveContainer(gomock.Any(), gomock.Any()).
+        Return(errors.New("Dummy Error")),
+        m.
+        EXPECT().
+        RemoveContainer(gomock.Any(), gomock.Any()).
+        Return(nil),
+    )
+
+    reaped, err := reap(context.Background(), m, time.Minute, now)
+    if err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+
+    if reaped != 1 {
+        t.Errorf("The second container should still be reaped.  Reaped %d", reaped)
+    }
+}
+
+func Test_NewLocalstack_SessionLabels(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    sqs, _ := NewLocalstackService("sqs")
+    services := &LocalstackServiceCollection {
+        *sqs,
+    }
+    m := getLocalstack_Empty(services, ctrl)
+
+    m.
+    EXPECT().
+    RunWithOptions(gomock.Any(), gomock.Any()).
+    Times(1).
+    Do(func(ctx context.Context, opts *dockertest.RunOptions, hcOpts ...func(*docker.HostConfig)) {
+        if opts.Labels[Localstack_OwnerLabel] != Owner || opts.Labels[Localstack_SessionLabel] != Session {
+            t.Errorf("The container should be labeled with the owner and session.  Received %v", opts.Labels)
+        }
+        if _, err := time.Parse(time.RFC3339, opts.Labels[Localstack_CreatedLabel]); err != nil {
+            t.Errorf("The container should be labeled with the time it was created.  Received %v", opts.Labels)
+        }
+    }).
+    Return(&dockertest.Resource{ }, nil)
+
+    m.
+    EXPECT().
+    Retry(gomock.Any(), gomock.Any()).
+    Times(1).
+    Return(nil)
+
+    if _, err := newLocalstack(context.Background(), services, m, testOptions...); err != nil {
+        t.Errorf("We were expecting the returned error to be nil: %s", err)
+    }
+}
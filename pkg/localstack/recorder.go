@@ -0,0 +1,134 @@
+package localstack
+
+import (
+    "fmt"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+    "github.com/aws/aws-sdk-go/aws/request"
+)
+
+// Call is an AWS operation captured by a Recorder.
+type Call struct {
+    // Service is the Localstack name of the service.  (I.E. "s3" or "cloudwatch")
+    Service string
+    // Operation is the name of the operation.  (I.E. "PutObject")
+    Operation string
+    // Params is the input of the operation.  (I.E. *s3.PutObjectInput)
+    Params interface{}
+    // StatusCode is the HTTP status code of the response, or 0 when no
+    // response was received.
+    StatusCode int
+    // Latency is how long the operation took, including retries.
+    Latency time.Duration
+    // Err is the error the operation returned, if any.
+    Err error
+}
+
+// Recorder captures the AWS operations made by the sessions it is installed on.
+//
+//     rec := localstack.NewRecorder()
+//     ls, _ := localstack.NewLocalstackWithOptions(services, localstack.WithRecorder(rec))
+//     svc := s3.New(ls.CreateAWSSession())
+//     ...
+//     rec.AssertCalled(t, "s3", "PutObject")
+//
+// It can also be installed on a single session.  (See Install)
+type Recorder struct {
+    mutex sync.Mutex
+    calls []Call
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+    return &Recorder{ }
+}
+
+// Install adds the handlers that capture operations.  (I.E. rec.Install(&sess.Handlers)
+// or SessionOptions { Handlers: []func(*request.Handlers) { rec.Install } })
+func (rec *Recorder) Install(handlers *request.Handlers) {
+    handlers.Complete.PushBack(func(r *request.Request) {
+        service := r.ClientInfo.ServiceName
+        if name, ok := endpointServices[service]; ok {
+            service = name
+        }
+
+        call := Call {
+            Service: service,
+            Operation: r.Operation.Name,
+            Params: r.Params,
+            Latency: time.Since(r.Time),
+            Err: r.Error,
+        }
+        if r.HTTPResponse != nil {
+            call.StatusCode = r.HTTPResponse.StatusCode
+        }
+
+        rec.mutex.Lock()
+        defer rec.mutex.Unlock()
+        rec.calls = append(rec.calls, call)
+    })
+}
+
+// Calls returns every operation captured, in the order they completed.
+func (rec *Recorder) Calls() []Call {
+    rec.mutex.Lock()
+    defer rec.mutex.Unlock()
+
+    return append([]Call{ }, rec.calls...)
+}
+
+// CallsTo returns the operations captured for the service and operation given.
+func (rec *Recorder) CallsTo(service, operation string) []Call {
+    var calls []Call
+    for _, call := range rec.Calls() {
+        if call.Service == service && call.Operation == operation {
+            calls = append(calls, call)
+        }
+    }
+    return calls
+}
+
+// Reset forgets every operation captured.
+func (rec *Recorder) Reset() {
+    rec.mutex.Lock()
+    defer rec.mutex.Unlock()
+
+    rec.calls = nil
+}
+
+// AssertCalled fails the test unless the operation was called on the service.
+// It returns whether the assertion passed.
+func (rec *Recorder) AssertCalled(t testing.TB, service, operation string) bool {
+    t.Helper()
+    if len(rec.CallsTo(service, operation)) == 0 {
+        t.Errorf("Expected a call to %s %s.  Received %s", service, operation, rec.summary())
+        return false
+    }
+    return true
+}
+
+// AssertNotCalled fails the test if the operation was called on the service.
+// It returns whether the assertion passed.
+func (rec *Recorder) AssertNotCalled(t testing.TB, service, operation string) bool {
+    t.Helper()
+    if calls := rec.CallsTo(service, operation); len(calls) > 0 {
+        t.Errorf("Expected no calls to %s %s.  Received %d", service, operation, len(calls))
+        return false
+    }
+    return true
+}
+
+// summary lists the operations captured for failure messages.
+func (rec *Recorder) summary() string {
+    calls := rec.Calls()
+    if len(calls) == 0 {
+        return "no calls"
+    }
+    var names []string
+    for _, call := range calls {
+        names = append(names, fmt.Sprintf("%s %s", call.Service, call.Operation))
+    }
+    return strings.Join(names, ", ")
+}
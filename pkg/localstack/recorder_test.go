@@ -0,0 +1,114 @@
+package localstack
+
+import (
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "github.com/ory/dockertest"
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/request"
+    "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// errorT records the failures of a test instead of failing it.
+type errorT struct {
+    recordingT
+    errors []string
+}
+
+func (e *errorT) Helper() { }
+func (e *errorT) Errorf(format string, args ...interface{}) {
+    e.errors = append(e.errors, fmt.Sprintf(format, args...))
+}
+
+func Test_Recorder(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(`<CreateQueueResponse><CreateQueueResult><QueueUrl>http://queue</QueueUrl></CreateQueueResult></CreateQueueResponse>`))
+    }))
+    defer server.Close()
+
+    sqsService, _ := NewLocalstackService("sqs")
+    ls := &Localstack {
+        Resource: &dockertest.Resource { Container: healthContainer(server) },
+        Services: &LocalstackServiceCollection { *sqsService },
+        portMode: PortModeEdge,
+    }
+
+    rec := NewRecorder()
+    sess, err := ls.CreateAWSSessionWithOptions(SessionOptions { Handlers: []func(*request.Handlers) { rec.Install } })
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if _, err := sqs.New(sess).CreateQueue(&sqs.CreateQueueInput { QueueName: aws.String("queue") }); err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    calls := rec.CallsTo("sqs", "CreateQueue")
+    if len(calls) != 1 {
+        t.Fatalf("The call should be captured.  Received %+v", rec.Calls())
+    }
+    if calls[0].StatusCode != http.StatusOK || calls[0].Latency <= 0 {
+        t.Errorf("The response should be captured.  Received %+v", calls[0])
+    }
+    if input, ok := calls[0].Params.(*sqs.CreateQueueInput); !ok || *input.QueueName != "queue" {
+        t.Errorf("The params should be captured.  Received %+v", calls[0].Params)
+    }
+
+    if !rec.AssertCalled(t, "sqs", "CreateQueue") || !rec.AssertNotCalled(t, "sqs", "DeleteQueue") {
+        t.Error("The assertions should pass.")
+    }
+
+    failing := &errorT { recordingT: recordingT { TB: t } }
+    if rec.AssertCalled(failing, "sqs", "DeleteQueue") || rec.AssertNotCalled(failing, "sqs", "CreateQueue") {
+        t.Error("The assertions should fail.")
+    }
+    if len(failing.errors) != 2 || !strings.Contains(failing.errors[0], "sqs CreateQueue") {
+        t.Errorf("The failures should list the calls made.  Received %v", failing.errors)
+    }
+
+    rec.Reset()
+    if len(rec.Calls()) != 0 {
+        t.Error("Reset should forget every call.")
+    }
+}
+
+func Test_WithRecorder(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Write([]byte(`<CreateQueueResponse><CreateQueueResult><QueueUrl>http://queue</QueueUrl></CreateQueueResult></CreateQueueResponse>`))
+    }))
+    defer server.Close()
+
+    rec := NewRecorder()
+    sqsService, _ := NewLocalstackService("sqs")
+    ls := &Localstack {
+        Resource: &dockertest.Resource { Container: healthContainer(server) },
+        Services: &LocalstackServiceCollection { *sqsService },
+        portMode: PortModeEdge,
+        options: newLocalstackOptions(WithRecorder(rec)),
+    }
+
+    if _, err := sqs.New(ls.CreateAWSSession()).CreateQueue(&sqs.CreateQueueInput { QueueName: aws.String("session") }); err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+    if !rec.AssertCalled(t, "sqs", "CreateQueue") {
+        t.Fatal("The calls of CreateAWSSession sessions should be captured.")
+    }
+
+    svc, err := ls.SQS()
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+    if _, err := svc.CreateQueue(&sqs.CreateQueueInput { QueueName: aws.String("accessor") }); err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+    if calls := rec.CallsTo("sqs", "CreateQueue"); len(calls) != 2 {
+        t.Errorf("The calls of the accessors should be captured.  Received %+v", rec.Calls())
+    }
+
+    if ls.internal().options.recorders != nil {
+        t.Error("The requests of the Localstack itself shouldn't be captured.")
+    }
+}
@@ -0,0 +1,242 @@
+package localstack
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/session"
+    "github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+    "github.com/aws/aws-sdk-go/service/dynamodb"
+    "github.com/aws/aws-sdk-go/service/kinesis"
+    "github.com/aws/aws-sdk-go/service/s3"
+    "github.com/aws/aws-sdk-go/service/s3/s3manager"
+    "github.com/aws/aws-sdk-go/service/secretsmanager"
+    "github.com/aws/aws-sdk-go/service/sns"
+    "github.com/aws/aws-sdk-go/service/sqs"
+    "github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// resetters removes every resource of a service, keyed by the service name.
+var resetters = map[string]func(aws.Context, *session.Session) error {
+    "s3": resetS3,
+    "dynamodb": resetDynamoDB,
+    "sqs": resetSQS,
+    "sns": resetSNS,
+    "kinesis": resetKinesis,
+    "ssm": resetSSM,
+    "secretsmanager": resetSecretsManager,
+    "logs": resetLogs,
+}
+
+// Reset removes every resource from the services named (I.E. "s3" or "sqs") in the
+// running Localstack instance, which is much faster than starting a new container.
+// Without any names, every requested service that can be reset is reset.  Only
+// services in Services can be reset.  The services supported are s3, dynamodb,
+// sqs, sns, kinesis, ssm, secretsmanager and logs.
+func (ls *Localstack) Reset(services ...string) error {
+    return ls.ResetContext(context.Background(), services...)
+}
+
+// ResetContext is Reset with a context that can be used to cancel the call.
+func (ls *Localstack) ResetContext(ctx context.Context, services ...string) error {
+    if len(services) == 0 {
+        for _, service := range *ls.Services {
+            if _, ok := resetters[service.Name]; ok {
+                services = append(services, service.Name)
+            }
+        }
+    }
+
+    for _, name := range services {
+        if !ls.Services.Contains(name) {
+            return errors.New(fmt.Sprintf("Unable to reset %s: it was not requested from Localstack", name))
+        }
+        if _, ok := resetters[name]; !ok {
+            return errors.New(fmt.Sprintf("Unable to reset %s: it is not supported", name))
+        }
+    }
+
+    // The faults of WithFaultProxy are meant for the code under test.
+    sess, err := ls.internal().CreateAWSSessionWithOptions(SessionOptions{ })
+    if err != nil {
+        return errors.New(fmt.Sprintf("Unable to reset Localstack: %s", err))
+    }
+    for _, name := range services {
+        if err := resetters[name](ctx, sess); err != nil {
+            return errors.New(fmt.Sprintf("Unable to reset %s: %s", name, err))
+        }
+    }
+    return nil
+}
+
+func resetS3(ctx aws.Context, sess *session.Session) error {
+    svc := s3.New(sess)
+    buckets, err := svc.ListBucketsWithContext(ctx, &s3.ListBucketsInput{ })
+    if err != nil {
+        return err
+    }
+    for _, bucket := range buckets.Buckets {
+        // A bucket can only be deleted once every version and delete marker of
+        // its objects is deleted, which listing the objects doesn't return.
+        var objects []s3manager.BatchDeleteObject
+        if err := svc.ListObjectVersionsPagesWithContext(ctx, &s3.ListObjectVersionsInput { Bucket: bucket.Name }, func(page *s3.ListObjectVersionsOutput, last bool) bool {
+            for _, version := range page.Versions {
+                objects = append(objects, s3manager.BatchDeleteObject { Object: &s3.DeleteObjectInput {
+                    Bucket: bucket.Name,
+                    Key: version.Key,
+                    VersionId: version.VersionId,
+                }})
+            }
+            for _, marker := range page.DeleteMarkers {
+                objects = append(objects, s3manager.BatchDeleteObject { Object: &s3.DeleteObjectInput {
+                    Bucket: bucket.Name,
+                    Key: marker.Key,
+                    VersionId: marker.VersionId,
+                }})
+            }
+            return true
+        }); err != nil {
+            return err
+        }
+        if err := s3manager.NewBatchDeleteWithClient(svc).Delete(ctx, &s3manager.DeleteObjectsIterator { Objects: objects }); err != nil {
+            return err
+        }
+        if _, err := svc.DeleteBucketWithContext(ctx, &s3.DeleteBucketInput { Bucket: bucket.Name }); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func resetDynamoDB(ctx aws.Context, sess *session.Session) error {
+    svc := dynamodb.New(sess)
+    var tables []*string
+    if err := svc.ListTablesPagesWithContext(ctx, &dynamodb.ListTablesInput{ }, func(page *dynamodb.ListTablesOutput, last bool) bool {
+        tables = append(tables, page.TableNames...)
+        return true
+    }); err != nil {
+        return err
+    }
+    for _, table := range tables {
+        if _, err := svc.DeleteTableWithContext(ctx, &dynamodb.DeleteTableInput { TableName: table }); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func resetSQS(ctx aws.Context, sess *session.Session) error {
+    svc := sqs.New(sess)
+    queues, err := svc.ListQueuesWithContext(ctx, &sqs.ListQueuesInput{ })
+    if err != nil {
+        return err
+    }
+    for _, queue := range queues.QueueUrls {
+        if _, err := svc.DeleteQueueWithContext(ctx, &sqs.DeleteQueueInput { QueueUrl: queue }); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func resetSNS(ctx aws.Context, sess *session.Session) error {
+    svc := sns.New(sess)
+    var topics []*string
+    if err := svc.ListTopicsPagesWithContext(ctx, &sns.ListTopicsInput{ }, func(page *sns.ListTopicsOutput, last bool) bool {
+        for _, topic := range page.Topics {
+            topics = append(topics, topic.TopicArn)
+        }
+        return true
+    }); err != nil {
+        return err
+    }
+    for _, topic := range topics {
+        if _, err := svc.DeleteTopicWithContext(ctx, &sns.DeleteTopicInput { TopicArn: topic }); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func resetKinesis(ctx aws.Context, sess *session.Session) error {
+    svc := kinesis.New(sess)
+    var streams []*string
+    if err := svc.ListStreamsPagesWithContext(ctx, &kinesis.ListStreamsInput{ }, func(page *kinesis.ListStreamsOutput, last bool) bool {
+        streams = append(streams, page.StreamNames...)
+        return true
+    }); err != nil {
+        return err
+    }
+    for _, stream := range streams {
+        if _, err := svc.DeleteStreamWithContext(ctx, &kinesis.DeleteStreamInput { StreamName: stream }); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func resetSSM(ctx aws.Context, sess *session.Session) error {
+    svc := ssm.New(sess)
+    var parameters []*string
+    if err := svc.DescribeParametersPagesWithContext(ctx, &ssm.DescribeParametersInput{ }, func(page *ssm.DescribeParametersOutput, last bool) bool {
+        for _, parameter := range page.Parameters {
+            parameters = append(parameters, parameter.Name)
+        }
+        return true
+    }); err != nil {
+        return err
+    }
+    // DeleteParameters accepts at most 10 names at a time.
+    for start := 0; start < len(parameters); start += 10 {
+        end := start + 10
+        if end > len(parameters) {
+            end = len(parameters)
+        }
+        if _, err := svc.DeleteParametersWithContext(ctx, &ssm.DeleteParametersInput { Names: parameters[start:end] }); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func resetSecretsManager(ctx aws.Context, sess *session.Session) error {
+    svc := secretsmanager.New(sess)
+    var secrets []*string
+    if err := svc.ListSecretsPagesWithContext(ctx, &secretsmanager.ListSecretsInput{ }, func(page *secretsmanager.ListSecretsOutput, last bool) bool {
+        for _, secret := range page.SecretList {
+            secrets = append(secrets, secret.ARN)
+        }
+        return true
+    }); err != nil {
+        return err
+    }
+    for _, secret := range secrets {
+        if _, err := svc.DeleteSecretWithContext(ctx, &secretsmanager.DeleteSecretInput {
+            SecretId: secret,
+            ForceDeleteWithoutRecovery: aws.Bool(true),
+        }); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func resetLogs(ctx aws.Context, sess *session.Session) error {
+    svc := cloudwatchlogs.New(sess)
+    var groups []*string
+    if err := svc.DescribeLogGroupsPagesWithContext(ctx, &cloudwatchlogs.DescribeLogGroupsInput{ }, func(page *cloudwatchlogs.DescribeLogGroupsOutput, last bool) bool {
+        for _, group := range page.LogGroups {
+            groups = append(groups, group.LogGroupName)
+        }
+        return true
+    }); err != nil {
+        return err
+    }
+    for _, group := range groups {
+        if _, err := svc.DeleteLogGroupWithContext(ctx, &cloudwatchlogs.DeleteLogGroupInput { LogGroupName: group }); err != nil {
+            return err
+        }
+    }
+    return nil
+}
@@ -0,0 +1,216 @@
+package localstack
+
+import (
+    "encoding/json"
+    "encoding/xml"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "sort"
+    "strings"
+    "testing"
+    "github.com/ory/dockertest"
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/session"
+)
+
+func resetLocalstack(server *httptest.Server, names ...string) *Localstack {
+    services := LocalstackServiceCollection{ }
+    for _, name := range names {
+        service, _ := NewLocalstackService(name)
+        services = append(services, *service)
+    }
+    return &Localstack {
+        Resource: &dockertest.Resource { Container: healthContainer(server) },
+        Services: &services,
+        portMode: PortModeEdge,
+    }
+}
+
+// stubResetters replaces every resetter with one that records the services reset.
+func stubResetters(reset *[]string) func() {
+    original := resetters
+    resetters = map[string]func(aws.Context, *session.Session) error{ }
+    for name := range original {
+        service := name
+        resetters[service] = func(aws.Context, *session.Session) error {
+            *reset = append(*reset, service)
+            return nil
+        }
+    }
+    return func() { resetters = original }
+}
+
+func Test_Reset_AllRequestedServices(t *testing.T) {
+    server := httptest.NewServer(http.NotFoundHandler())
+    defer server.Close()
+
+    var reset []string
+    defer stubResetters(&reset)()
+
+    ls := resetLocalstack(server, "sqs", "lambda", "s3")
+    if err := ls.Reset(); err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    sort.Strings(reset)
+    if strings.Join(reset, ",") != "s3,sqs" {
+        t.Errorf("Only the requested services that support reset should be reset.  Received %v", reset)
+    }
+}
+
+func Test_Reset_ServiceNotRequested(t *testing.T) {
+    server := httptest.NewServer(http.NotFoundHandler())
+    defer server.Close()
+
+    var reset []string
+    defer stubResetters(&reset)()
+
+    ls := resetLocalstack(server, "sqs")
+    if err := ls.Reset("sqs", "s3"); err == nil {
+        t.Error("A service that wasn't requested should not be reset.")
+    }
+
+    if len(reset) != 0 {
+        t.Errorf("Nothing should be reset when a service is invalid.  Received %v", reset)
+    }
+}
+
+func Test_Reset_ServiceNotSupported(t *testing.T) {
+    server := httptest.NewServer(http.NotFoundHandler())
+    defer server.Close()
+
+    ls := resetLocalstack(server, "lambda")
+    if err := ls.Reset("lambda"); err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+}
+
+func Test_Reset_DynamoDB(t *testing.T) {
+    var deleted []string
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        var body map[string]interface{}
+        json.NewDecoder(r.Body).Decode(&body)
+
+        switch r.Header.Get("X-Amz-Target") {
+        case "DynamoDB_20120810.ListTables":
+            w.Write([]byte(`{"TableNames": ["first", "second"]}`))
+        case "DynamoDB_20120810.DeleteTable":
+            deleted = append(deleted, body["TableName"].(string))
+            w.Write([]byte(`{}`))
+        default:
+            t.Errorf("Unexpected operation: %s", r.Header.Get("X-Amz-Target"))
+        }
+    }))
+    defer server.Close()
+
+    ls := resetLocalstack(server, "dynamodb")
+    if err := ls.Reset("dynamodb"); err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if strings.Join(deleted, ",") != "first,second" {
+        t.Errorf("Every table should be deleted.  Received %v", deleted)
+    }
+}
+
+func Test_Reset_S3(t *testing.T) {
+    var operations []string
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch {
+        case r.Method == "GET" && r.URL.Path == "/":
+            w.Write([]byte(`<ListAllMyBucketsResult><Buckets><Bucket><Name>bucket</Name></Bucket></Buckets></ListAllMyBucketsResult>`))
+        case r.Method == "GET" && r.URL.Path == "/bucket" && r.URL.Query()["versions"] != nil:
+            w.Write([]byte(`<ListVersionsResult><Name>bucket</Name><IsTruncated>false</IsTruncated>` +
+                `<Version><Key>first</Key><VersionId>1</VersionId></Version>` +
+                `<Version><Key>first</Key><VersionId>2</VersionId></Version>` +
+                `<DeleteMarker><Key>second</Key><VersionId>3</VersionId></DeleteMarker></ListVersionsResult>`))
+        case r.Method == "POST" && r.URL.Path == "/bucket" && r.URL.Query()["delete"] != nil:
+            var body struct {
+                Objects []struct { Key, VersionId string } `xml:"Object"`
+            }
+            xml.NewDecoder(r.Body).Decode(&body)
+            for _, object := range body.Objects {
+                operations = append(operations, fmt.Sprintf("DeleteObject %s@%s", object.Key, object.VersionId))
+            }
+            w.Write([]byte(`<DeleteResult></DeleteResult>`))
+        case r.Method == "DELETE" && r.URL.Path == "/bucket":
+            operations = append(operations, "DeleteBucket bucket")
+            w.WriteHeader(http.StatusNoContent)
+        default:
+            t.Errorf("Unexpected request: %s %s", r.Method, r.URL)
+        }
+    }))
+    defer server.Close()
+
+    ls := resetLocalstack(server, "s3")
+    if err := ls.Reset("s3"); err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    expected := "DeleteObject first@1,DeleteObject first@2,DeleteObject second@3,DeleteBucket bucket"
+    if strings.Join(operations, ",") != expected {
+        t.Errorf("Every version and delete marker should be deleted before the bucket.  Received %v", operations)
+    }
+}
+
+func Test_Reset_SQS(t *testing.T) {
+    var deleted []string
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        r.ParseForm()
+
+        switch r.Form.Get("Action") {
+        case "ListQueues":
+            w.Write([]byte(`<ListQueuesResponse><ListQueuesResult>` +
+                `<QueueUrl>http://localhost:4566/queue/first</QueueUrl>` +
+                `<QueueUrl>http://localhost:4566/queue/second</QueueUrl></ListQueuesResult></ListQueuesResponse>`))
+        case "DeleteQueue":
+            deleted = append(deleted, r.Form.Get("QueueUrl"))
+            w.Write([]byte(`<DeleteQueueResponse></DeleteQueueResponse>`))
+        default:
+            t.Errorf("Unexpected operation: %s", r.Form.Get("Action"))
+        }
+    }))
+    defer server.Close()
+
+    ls := resetLocalstack(server, "sqs")
+    if err := ls.Reset("sqs"); err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if strings.Join(deleted, ",") != "http://localhost:4566/queue/first,http://localhost:4566/queue/second" {
+        t.Errorf("Every queue should be deleted.  Received %v", deleted)
+    }
+}
+
+func Test_Reset_SSM(t *testing.T) {
+    var batches []int
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        var body map[string]interface{}
+        json.NewDecoder(r.Body).Decode(&body)
+
+        switch r.Header.Get("X-Amz-Target") {
+        case "AmazonSSM.DescribeParameters":
+            var parameters []map[string]string
+            for i := 0; i < 23; i++ {
+                parameters = append(parameters, map[string]string { "Name": fmt.Sprintf("parameter%d", i) })
+            }
+            json.NewEncoder(w).Encode(map[string]interface{} { "Parameters": parameters })
+        case "AmazonSSM.DeleteParameters":
+            batches = append(batches, len(body["Names"].([]interface{})))
+            w.Write([]byte(`{}`))
+        default:
+            t.Errorf("Unexpected operation: %s", r.Header.Get("X-Amz-Target"))
+        }
+    }))
+    defer server.Close()
+
+    ls := resetLocalstack(server, "ssm")
+    if err := ls.Reset("ssm"); err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if fmt.Sprint(batches) != "[10 10 3]" {
+        t.Errorf("The parameters should be deleted in batches of 10.  Received %v", batches)
+    }
+}
@@ -0,0 +1,354 @@
+package localstack
+
+import (
+    "net/http"
+    "net/url"
+    "regexp"
+    "strings"
+    "sync"
+)
+
+// restOperation is an operation of a service that uses a REST protocol, which
+// is only told apart from the others by its method, path, query and headers.
+type restOperation struct {
+    method string
+    // path is the path of the operation in the format of the SDK's models.
+    // (I.E. "/{Bucket}/{Key+}?uploads")
+    path string
+    // header is a header the request has to have, if any.
+    header string
+    name string
+}
+
+// restOperations are the operations of the services that use REST protocols,
+// keyed by the Localstack name of the service.  They are taken from the models
+// of the SDK.
+var restOperations = map[string][]restOperation {
+    "s3": {
+        { "PUT", "/{Bucket}", "", "CreateBucket" },
+        { "POST", "/{Bucket}/{Key+}?uploads", "", "CreateMultipartUpload" },
+        { "DELETE", "/{Bucket}", "", "DeleteBucket" },
+        { "DELETE", "/{Bucket}?analytics", "", "DeleteBucketAnalyticsConfiguration" },
+        { "DELETE", "/{Bucket}?cors", "", "DeleteBucketCors" },
+        { "DELETE", "/{Bucket}?encryption", "", "DeleteBucketEncryption" },
+        { "DELETE", "/{Bucket}?inventory", "", "DeleteBucketInventoryConfiguration" },
+        { "DELETE", "/{Bucket}?lifecycle", "", "DeleteBucketLifecycle" },
+        { "DELETE", "/{Bucket}?metrics", "", "DeleteBucketMetricsConfiguration" },
+        { "DELETE", "/{Bucket}?policy", "", "DeleteBucketPolicy" },
+        { "DELETE", "/{Bucket}?replication", "", "DeleteBucketReplication" },
+        { "DELETE", "/{Bucket}?tagging", "", "DeleteBucketTagging" },
+        { "DELETE", "/{Bucket}?website", "", "DeleteBucketWebsite" },
+        { "DELETE", "/{Bucket}/{Key+}", "", "DeleteObject" },
+        { "DELETE", "/{Bucket}/{Key+}?tagging", "", "DeleteObjectTagging" },
+        { "POST", "/{Bucket}?delete", "", "DeleteObjects" },
+        { "DELETE", "/{Bucket}?publicAccessBlock", "", "DeletePublicAccessBlock" },
+        { "GET", "/{Bucket}?accelerate", "", "GetBucketAccelerateConfiguration" },
+        { "GET", "/{Bucket}?acl", "", "GetBucketAcl" },
+        { "GET", "/{Bucket}?analytics&id", "", "GetBucketAnalyticsConfiguration" },
+        { "GET", "/{Bucket}?cors", "", "GetBucketCors" },
+        { "GET", "/{Bucket}?encryption", "", "GetBucketEncryption" },
+        { "GET", "/{Bucket}?inventory&id", "", "GetBucketInventoryConfiguration" },
+        { "GET", "/{Bucket}?lifecycle", "", "GetBucketLifecycleConfiguration" },
+        { "GET", "/{Bucket}?location", "", "GetBucketLocation" },
+        { "GET", "/{Bucket}?logging", "", "GetBucketLogging" },
+        { "GET", "/{Bucket}?metrics&id", "", "GetBucketMetricsConfiguration" },
+        { "GET", "/{Bucket}?notification", "", "GetBucketNotificationConfiguration" },
+        { "GET", "/{Bucket}?policy", "", "GetBucketPolicy" },
+        { "GET", "/{Bucket}?policyStatus", "", "GetBucketPolicyStatus" },
+        { "GET", "/{Bucket}?replication", "", "GetBucketReplication" },
+        { "GET", "/{Bucket}?requestPayment", "", "GetBucketRequestPayment" },
+        { "GET", "/{Bucket}?tagging", "", "GetBucketTagging" },
+        { "GET", "/{Bucket}?versioning", "", "GetBucketVersioning" },
+        { "GET", "/{Bucket}?website", "", "GetBucketWebsite" },
+        { "GET", "/{Bucket}/{Key+}", "", "GetObject" },
+        { "GET", "/{Bucket}/{Key+}?acl", "", "GetObjectAcl" },
+        { "GET", "/{Bucket}/{Key+}?legal-hold", "", "GetObjectLegalHold" },
+        { "GET", "/{Bucket}?object-lock", "", "GetObjectLockConfiguration" },
+        { "GET", "/{Bucket}/{Key+}?retention", "", "GetObjectRetention" },
+        { "GET", "/{Bucket}/{Key+}?tagging", "", "GetObjectTagging" },
+        { "GET", "/{Bucket}/{Key+}?torrent", "", "GetObjectTorrent" },
+        { "GET", "/{Bucket}?publicAccessBlock", "", "GetPublicAccessBlock" },
+        { "HEAD", "/{Bucket}", "", "HeadBucket" },
+        { "HEAD", "/{Bucket}/{Key+}", "", "HeadObject" },
+        { "GET", "/{Bucket}?analytics", "", "ListBucketAnalyticsConfigurations" },
+        { "GET", "/{Bucket}?inventory", "", "ListBucketInventoryConfigurations" },
+        { "GET", "/{Bucket}?metrics", "", "ListBucketMetricsConfigurations" },
+        { "GET", "/", "", "ListBuckets" },
+        { "GET", "/{Bucket}?uploads", "", "ListMultipartUploads" },
+        { "GET", "/{Bucket}?versions", "", "ListObjectVersions" },
+        { "GET", "/{Bucket}", "", "ListObjects" },
+        { "GET", "/{Bucket}?list-type=2", "", "ListObjectsV2" },
+        { "PUT", "/{Bucket}?accelerate", "", "PutBucketAccelerateConfiguration" },
+        { "PUT", "/{Bucket}?acl", "", "PutBucketAcl" },
+        { "PUT", "/{Bucket}?analytics", "", "PutBucketAnalyticsConfiguration" },
+        { "PUT", "/{Bucket}?cors", "", "PutBucketCors" },
+        { "PUT", "/{Bucket}?encryption", "", "PutBucketEncryption" },
+        { "PUT", "/{Bucket}?inventory", "", "PutBucketInventoryConfiguration" },
+        { "PUT", "/{Bucket}?lifecycle", "", "PutBucketLifecycleConfiguration" },
+        { "PUT", "/{Bucket}?logging", "", "PutBucketLogging" },
+        { "PUT", "/{Bucket}?metrics", "", "PutBucketMetricsConfiguration" },
+        { "PUT", "/{Bucket}?notification", "", "PutBucketNotificationConfiguration" },
+        { "PUT", "/{Bucket}?policy", "", "PutBucketPolicy" },
+        { "PUT", "/{Bucket}?replication", "", "PutBucketReplication" },
+        { "PUT", "/{Bucket}?requestPayment", "", "PutBucketRequestPayment" },
+        { "PUT", "/{Bucket}?tagging", "", "PutBucketTagging" },
+        { "PUT", "/{Bucket}?versioning", "", "PutBucketVersioning" },
+        { "PUT", "/{Bucket}?website", "", "PutBucketWebsite" },
+        { "PUT", "/{Bucket}/{Key+}", "", "PutObject" },
+        { "PUT", "/{Bucket}/{Key+}?acl", "", "PutObjectAcl" },
+        { "PUT", "/{Bucket}/{Key+}?legal-hold", "", "PutObjectLegalHold" },
+        { "PUT", "/{Bucket}?object-lock", "", "PutObjectLockConfiguration" },
+        { "PUT", "/{Bucket}/{Key+}?retention", "", "PutObjectRetention" },
+        { "PUT", "/{Bucket}/{Key+}?tagging", "", "PutObjectTagging" },
+        { "PUT", "/{Bucket}?publicAccessBlock", "", "PutPublicAccessBlock" },
+        { "POST", "/{Bucket}/{Key+}?restore", "", "RestoreObject" },
+        { "PUT", "/{Bucket}/{Key+}", "X-Amz-Copy-Source", "CopyObject" },
+        { "PUT", "/{Bucket}/{Key+}?partNumber&uploadId", "", "UploadPart" },
+        { "PUT", "/{Bucket}/{Key+}?partNumber&uploadId", "X-Amz-Copy-Source", "UploadPartCopy" },
+        { "GET", "/{Bucket}/{Key+}?uploadId", "", "ListParts" },
+        { "DELETE", "/{Bucket}/{Key+}?uploadId", "", "AbortMultipartUpload" },
+        { "POST", "/{Bucket}/{Key+}?uploadId", "", "CompleteMultipartUpload" },
+        { "POST", "/{Bucket}/{Key+}?select&select-type=2", "", "SelectObjectContent" },
+    },
+    "lambda": {
+        { "POST", "/2018-10-31/layers/{LayerName}/versions/{VersionNumber}/policy", "", "AddLayerVersionPermission" },
+        { "POST", "/2015-03-31/functions/{FunctionName}/policy", "", "AddPermission" },
+        { "POST", "/2015-03-31/functions/{FunctionName}/aliases", "", "CreateAlias" },
+        { "POST", "/2015-03-31/event-source-mappings/", "", "CreateEventSourceMapping" },
+        { "POST", "/2015-03-31/functions", "", "CreateFunction" },
+        { "DELETE", "/2015-03-31/functions/{FunctionName}/aliases/{Name}", "", "DeleteAlias" },
+        { "DELETE", "/2015-03-31/event-source-mappings/{UUID}", "", "DeleteEventSourceMapping" },
+        { "DELETE", "/2015-03-31/functions/{FunctionName}", "", "DeleteFunction" },
+        { "DELETE", "/2017-10-31/functions/{FunctionName}/concurrency", "", "DeleteFunctionConcurrency" },
+        { "DELETE", "/2018-10-31/layers/{LayerName}/versions/{VersionNumber}", "", "DeleteLayerVersion" },
+        { "GET", "/2016-08-19/account-settings/", "", "GetAccountSettings" },
+        { "GET", "/2015-03-31/functions/{FunctionName}/aliases/{Name}", "", "GetAlias" },
+        { "GET", "/2015-03-31/event-source-mappings/{UUID}", "", "GetEventSourceMapping" },
+        { "GET", "/2015-03-31/functions/{FunctionName}", "", "GetFunction" },
+        { "GET", "/2015-03-31/functions/{FunctionName}/configuration", "", "GetFunctionConfiguration" },
+        { "GET", "/2018-10-31/layers/{LayerName}/versions/{VersionNumber}", "", "GetLayerVersion" },
+        { "GET", "/2018-10-31/layers?find=LayerVersion", "", "GetLayerVersionByArn" },
+        { "GET", "/2018-10-31/layers/{LayerName}/versions/{VersionNumber}/policy", "", "GetLayerVersionPolicy" },
+        { "GET", "/2015-03-31/functions/{FunctionName}/policy", "", "GetPolicy" },
+        { "POST", "/2015-03-31/functions/{FunctionName}/invocations", "", "Invoke" },
+        { "POST", "/2014-11-13/functions/{FunctionName}/invoke-async/", "", "InvokeAsync" },
+        { "GET", "/2015-03-31/functions/{FunctionName}/aliases", "", "ListAliases" },
+        { "GET", "/2015-03-31/event-source-mappings/", "", "ListEventSourceMappings" },
+        { "GET", "/2015-03-31/functions/", "", "ListFunctions" },
+        { "GET", "/2018-10-31/layers/{LayerName}/versions", "", "ListLayerVersions" },
+        { "GET", "/2018-10-31/layers", "", "ListLayers" },
+        { "GET", "/2017-03-31/tags/{ARN}", "", "ListTags" },
+        { "GET", "/2015-03-31/functions/{FunctionName}/versions", "", "ListVersionsByFunction" },
+        { "POST", "/2018-10-31/layers/{LayerName}/versions", "", "PublishLayerVersion" },
+        { "POST", "/2015-03-31/functions/{FunctionName}/versions", "", "PublishVersion" },
+        { "PUT", "/2017-10-31/functions/{FunctionName}/concurrency", "", "PutFunctionConcurrency" },
+        { "DELETE", "/2018-10-31/layers/{LayerName}/versions/{VersionNumber}/policy/{StatementId}", "", "RemoveLayerVersionPermission" },
+        { "DELETE", "/2015-03-31/functions/{FunctionName}/policy/{StatementId}", "", "RemovePermission" },
+        { "POST", "/2017-03-31/tags/{ARN}", "", "TagResource" },
+        { "DELETE", "/2017-03-31/tags/{ARN}", "", "UntagResource" },
+        { "PUT", "/2015-03-31/functions/{FunctionName}/aliases/{Name}", "", "UpdateAlias" },
+        { "PUT", "/2015-03-31/event-source-mappings/{UUID}", "", "UpdateEventSourceMapping" },
+        { "PUT", "/2015-03-31/functions/{FunctionName}/code", "", "UpdateFunctionCode" },
+        { "PUT", "/2015-03-31/functions/{FunctionName}/configuration", "", "UpdateFunctionConfiguration" },
+    },
+    "apigateway": {
+        { "POST", "/apikeys", "", "CreateApiKey" },
+        { "POST", "/restapis/{restapi_id}/authorizers", "", "CreateAuthorizer" },
+        { "POST", "/domainnames/{domain_name}/basepathmappings", "", "CreateBasePathMapping" },
+        { "POST", "/restapis/{restapi_id}/deployments", "", "CreateDeployment" },
+        { "POST", "/restapis/{restapi_id}/documentation/parts", "", "CreateDocumentationPart" },
+        { "POST", "/restapis/{restapi_id}/documentation/versions", "", "CreateDocumentationVersion" },
+        { "POST", "/domainnames", "", "CreateDomainName" },
+        { "POST", "/restapis/{restapi_id}/models", "", "CreateModel" },
+        { "POST", "/restapis/{restapi_id}/requestvalidators", "", "CreateRequestValidator" },
+        { "POST", "/restapis/{restapi_id}/resources/{parent_id}", "", "CreateResource" },
+        { "POST", "/restapis", "", "CreateRestApi" },
+        { "POST", "/restapis/{restapi_id}/stages", "", "CreateStage" },
+        { "POST", "/usageplans", "", "CreateUsagePlan" },
+        { "POST", "/usageplans/{usageplanId}/keys", "", "CreateUsagePlanKey" },
+        { "POST", "/vpclinks", "", "CreateVpcLink" },
+        { "DELETE", "/apikeys/{api_Key}", "", "DeleteApiKey" },
+        { "DELETE", "/restapis/{restapi_id}/authorizers/{authorizer_id}", "", "DeleteAuthorizer" },
+        { "DELETE", "/domainnames/{domain_name}/basepathmappings/{base_path}", "", "DeleteBasePathMapping" },
+        { "DELETE", "/clientcertificates/{clientcertificate_id}", "", "DeleteClientCertificate" },
+        { "DELETE", "/restapis/{restapi_id}/deployments/{deployment_id}", "", "DeleteDeployment" },
+        { "DELETE", "/restapis/{restapi_id}/documentation/parts/{part_id}", "", "DeleteDocumentationPart" },
+        { "DELETE", "/restapis/{restapi_id}/documentation/versions/{doc_version}", "", "DeleteDocumentationVersion" },
+        { "DELETE", "/domainnames/{domain_name}", "", "DeleteDomainName" },
+        { "DELETE", "/restapis/{restapi_id}/gatewayresponses/{response_type}", "", "DeleteGatewayResponse" },
+        { "DELETE", "/restapis/{restapi_id}/resources/{resource_id}/methods/{http_method}/integration", "", "DeleteIntegration" },
+        { "DELETE", "/restapis/{restapi_id}/resources/{resource_id}/methods/{http_method}/integration/responses/{status_code}", "", "DeleteIntegrationResponse" },
+        { "DELETE", "/restapis/{restapi_id}/resources/{resource_id}/methods/{http_method}", "", "DeleteMethod" },
+        { "DELETE", "/restapis/{restapi_id}/resources/{resource_id}/methods/{http_method}/responses/{status_code}", "", "DeleteMethodResponse" },
+        { "DELETE", "/restapis/{restapi_id}/models/{model_name}", "", "DeleteModel" },
+        { "DELETE", "/restapis/{restapi_id}/requestvalidators/{requestvalidator_id}", "", "DeleteRequestValidator" },
+        { "DELETE", "/restapis/{restapi_id}/resources/{resource_id}", "", "DeleteResource" },
+        { "DELETE", "/restapis/{restapi_id}", "", "DeleteRestApi" },
+        { "DELETE", "/restapis/{restapi_id}/stages/{stage_name}", "", "DeleteStage" },
+        { "DELETE", "/usageplans/{usageplanId}", "", "DeleteUsagePlan" },
+        { "DELETE", "/usageplans/{usageplanId}/keys/{keyId}", "", "DeleteUsagePlanKey" },
+        { "DELETE", "/vpclinks/{vpclink_id}", "", "DeleteVpcLink" },
+        { "DELETE", "/restapis/{restapi_id}/stages/{stage_name}/cache/authorizers", "", "FlushStageAuthorizersCache" },
+        { "DELETE", "/restapis/{restapi_id}/stages/{stage_name}/cache/data", "", "FlushStageCache" },
+        { "POST", "/clientcertificates", "", "GenerateClientCertificate" },
+        { "GET", "/account", "", "GetAccount" },
+        { "GET", "/apikeys/{api_Key}", "", "GetApiKey" },
+        { "GET", "/apikeys", "", "GetApiKeys" },
+        { "GET", "/restapis/{restapi_id}/authorizers/{authorizer_id}", "", "GetAuthorizer" },
+        { "GET", "/restapis/{restapi_id}/authorizers", "", "GetAuthorizers" },
+        { "GET", "/domainnames/{domain_name}/basepathmappings/{base_path}", "", "GetBasePathMapping" },
+        { "GET", "/domainnames/{domain_name}/basepathmappings", "", "GetBasePathMappings" },
+        { "GET", "/clientcertificates/{clientcertificate_id}", "", "GetClientCertificate" },
+        { "GET", "/clientcertificates", "", "GetClientCertificates" },
+        { "GET", "/restapis/{restapi_id}/deployments/{deployment_id}", "", "GetDeployment" },
+        { "GET", "/restapis/{restapi_id}/deployments", "", "GetDeployments" },
+        { "GET", "/restapis/{restapi_id}/documentation/parts/{part_id}", "", "GetDocumentationPart" },
+        { "GET", "/restapis/{restapi_id}/documentation/parts", "", "GetDocumentationParts" },
+        { "GET", "/restapis/{restapi_id}/documentation/versions/{doc_version}", "", "GetDocumentationVersion" },
+        { "GET", "/restapis/{restapi_id}/documentation/versions", "", "GetDocumentationVersions" },
+        { "GET", "/domainnames/{domain_name}", "", "GetDomainName" },
+        { "GET", "/domainnames", "", "GetDomainNames" },
+        { "GET", "/restapis/{restapi_id}/stages/{stage_name}/exports/{export_type}", "", "GetExport" },
+        { "GET", "/restapis/{restapi_id}/gatewayresponses/{response_type}", "", "GetGatewayResponse" },
+        { "GET", "/restapis/{restapi_id}/gatewayresponses", "", "GetGatewayResponses" },
+        { "GET", "/restapis/{restapi_id}/resources/{resource_id}/methods/{http_method}/integration", "", "GetIntegration" },
+        { "GET", "/restapis/{restapi_id}/resources/{resource_id}/methods/{http_method}/integration/responses/{status_code}", "", "GetIntegrationResponse" },
+        { "GET", "/restapis/{restapi_id}/resources/{resource_id}/methods/{http_method}", "", "GetMethod" },
+        { "GET", "/restapis/{restapi_id}/resources/{resource_id}/methods/{http_method}/responses/{status_code}", "", "GetMethodResponse" },
+        { "GET", "/restapis/{restapi_id}/models/{model_name}", "", "GetModel" },
+        { "GET", "/restapis/{restapi_id}/models/{model_name}/default_template", "", "GetModelTemplate" },
+        { "GET", "/restapis/{restapi_id}/models", "", "GetModels" },
+        { "GET", "/restapis/{restapi_id}/requestvalidators/{requestvalidator_id}", "", "GetRequestValidator" },
+        { "GET", "/restapis/{restapi_id}/requestvalidators", "", "GetRequestValidators" },
+        { "GET", "/restapis/{restapi_id}/resources/{resource_id}", "", "GetResource" },
+        { "GET", "/restapis/{restapi_id}/resources", "", "GetResources" },
+        { "GET", "/restapis/{restapi_id}", "", "GetRestApi" },
+        { "GET", "/restapis", "", "GetRestApis" },
+        { "GET", "/restapis/{restapi_id}/stages/{stage_name}/sdks/{sdk_type}", "", "GetSdk" },
+        { "GET", "/sdktypes/{sdktype_id}", "", "GetSdkType" },
+        { "GET", "/sdktypes", "", "GetSdkTypes" },
+        { "GET", "/restapis/{restapi_id}/stages/{stage_name}", "", "GetStage" },
+        { "GET", "/restapis/{restapi_id}/stages", "", "GetStages" },
+        { "GET", "/tags/{resource_arn}", "", "GetTags" },
+        { "GET", "/usageplans/{usageplanId}/usage", "", "GetUsage" },
+        { "GET", "/usageplans/{usageplanId}", "", "GetUsagePlan" },
+        { "GET", "/usageplans/{usageplanId}/keys/{keyId}", "", "GetUsagePlanKey" },
+        { "GET", "/usageplans/{usageplanId}/keys", "", "GetUsagePlanKeys" },
+        { "GET", "/usageplans", "", "GetUsagePlans" },
+        { "GET", "/vpclinks/{vpclink_id}", "", "GetVpcLink" },
+        { "GET", "/vpclinks", "", "GetVpcLinks" },
+        { "POST", "/apikeys?mode=import", "", "ImportApiKeys" },
+        { "PUT", "/restapis/{restapi_id}/documentation/parts", "", "ImportDocumentationParts" },
+        { "POST", "/restapis?mode=import", "", "ImportRestApi" },
+        { "PUT", "/restapis/{restapi_id}/gatewayresponses/{response_type}", "", "PutGatewayResponse" },
+        { "PUT", "/restapis/{restapi_id}/resources/{resource_id}/methods/{http_method}/integration", "", "PutIntegration" },
+        { "PUT", "/restapis/{restapi_id}/resources/{resource_id}/methods/{http_method}/integration/responses/{status_code}", "", "PutIntegrationResponse" },
+        { "PUT", "/restapis/{restapi_id}/resources/{resource_id}/methods/{http_method}", "", "PutMethod" },
+        { "PUT", "/restapis/{restapi_id}/resources/{resource_id}/methods/{http_method}/responses/{status_code}", "", "PutMethodResponse" },
+        { "PUT", "/restapis/{restapi_id}", "", "PutRestApi" },
+        { "PUT", "/tags/{resource_arn}", "", "TagResource" },
+        { "POST", "/restapis/{restapi_id}/authorizers/{authorizer_id}", "", "TestInvokeAuthorizer" },
+        { "POST", "/restapis/{restapi_id}/resources/{resource_id}/methods/{http_method}", "", "TestInvokeMethod" },
+        { "DELETE", "/tags/{resource_arn}", "", "UntagResource" },
+        { "PATCH", "/account", "", "UpdateAccount" },
+        { "PATCH", "/apikeys/{api_Key}", "", "UpdateApiKey" },
+        { "PATCH", "/restapis/{restapi_id}/authorizers/{authorizer_id}", "", "UpdateAuthorizer" },
+        { "PATCH", "/domainnames/{domain_name}/basepathmappings/{base_path}", "", "UpdateBasePathMapping" },
+        { "PATCH", "/clientcertificates/{clientcertificate_id}", "", "UpdateClientCertificate" },
+        { "PATCH", "/restapis/{restapi_id}/deployments/{deployment_id}", "", "UpdateDeployment" },
+        { "PATCH", "/restapis/{restapi_id}/documentation/parts/{part_id}", "", "UpdateDocumentationPart" },
+        { "PATCH", "/restapis/{restapi_id}/documentation/versions/{doc_version}", "", "UpdateDocumentationVersion" },
+        { "PATCH", "/domainnames/{domain_name}", "", "UpdateDomainName" },
+        { "PATCH", "/restapis/{restapi_id}/gatewayresponses/{response_type}", "", "UpdateGatewayResponse" },
+        { "PATCH", "/restapis/{restapi_id}/resources/{resource_id}/methods/{http_method}/integration", "", "UpdateIntegration" },
+        { "PATCH", "/restapis/{restapi_id}/resources/{resource_id}/methods/{http_method}/integration/responses/{status_code}", "", "UpdateIntegrationResponse" },
+        { "PATCH", "/restapis/{restapi_id}/resources/{resource_id}/methods/{http_method}", "", "UpdateMethod" },
+        { "PATCH", "/restapis/{restapi_id}/resources/{resource_id}/methods/{http_method}/responses/{status_code}", "", "UpdateMethodResponse" },
+        { "PATCH", "/restapis/{restapi_id}/models/{model_name}", "", "UpdateModel" },
+        { "PATCH", "/restapis/{restapi_id}/requestvalidators/{requestvalidator_id}", "", "UpdateRequestValidator" },
+        { "PATCH", "/restapis/{restapi_id}/resources/{resource_id}", "", "UpdateResource" },
+        { "PATCH", "/restapis/{restapi_id}", "", "UpdateRestApi" },
+        { "PATCH", "/restapis/{restapi_id}/stages/{stage_name}", "", "UpdateStage" },
+        { "PATCH", "/usageplans/{usageplanId}/keys/{keyId}/usage", "", "UpdateUsage" },
+        { "PATCH", "/usageplans/{usageplanId}", "", "UpdateUsagePlan" },
+        { "PATCH", "/vpclinks/{vpclink_id}", "", "UpdateVpcLink" },
+    },
+}
+
+// restPattern is a restOperation compiled for matching requests.
+type restPattern struct {
+    restOperation
+    path *regexp.Regexp
+    query url.Values
+}
+
+var restPatterns map[string][]restPattern
+var restPatternsOnce sync.Once
+
+var restLabelPattern = regexp.MustCompile(`\\\{[^}]+\\\}`)
+
+func compileRestOperations() {
+    restPatterns = map[string][]restPattern{ }
+    for service, operations := range restOperations {
+        for _, operation := range operations {
+            parts := strings.SplitN(operation.path, "?", 2)
+            // A greedy label (I.E. "{Key+}") spans slashes, other labels don't.
+            path := restLabelPattern.ReplaceAllStringFunc(regexp.QuoteMeta(strings.TrimSuffix(parts[0], "/")), func(label string) string {
+                if strings.HasSuffix(label, `+\}`) {
+                    return ".+"
+                }
+                return "[^/]+"
+            })
+            pattern := restPattern {
+                restOperation: operation,
+                path: regexp.MustCompile("^" + path + "/?$"),
+                query: url.Values{ },
+            }
+            if len(parts) == 2 {
+                pattern.query, _ = url.ParseQuery(parts[1])
+            }
+            restPatterns[service] = append(restPatterns[service], pattern)
+        }
+    }
+}
+
+// matches returns how specific the match of the pattern to the request is,
+// or -1 when it doesn't match.
+func (p restPattern) matches(r *http.Request) int {
+    if p.method != r.Method || !p.path.MatchString(r.URL.EscapedPath()) {
+        return -1
+    }
+    specificity := 0
+    query := r.URL.Query()
+    for key, values := range p.query {
+        if _, ok := query[key]; !ok {
+            return -1
+        }
+        if values[0] != "" && query.Get(key) != values[0] {
+            return -1
+        }
+        specificity++
+    }
+    if p.header != "" {
+        if r.Header.Get(p.header) == "" {
+            return -1
+        }
+        specificity++
+    }
+    return specificity
+}
+
+// restOperationName returns the name of the operation a request to a service
+// that uses a REST protocol is for, or "" when it isn't known.  When several
+// operations match, the one that needs the most query parameters and headers wins.
+func restOperationName(service string, r *http.Request) string {
+    restPatternsOnce.Do(compileRestOperations)
+
+    name, best := "", -1
+    for _, pattern := range restPatterns[service] {
+        if specificity := pattern.matches(r); specificity > best {
+            name, best = pattern.name, specificity
+        }
+    }
+    return name
+}
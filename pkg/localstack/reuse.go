@@ -0,0 +1,97 @@
+package localstack
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+    "github.com/ory/dockertest"
+    "github.com/ory/dockertest/docker"
+)
+
+// ReusePolicy controls what happens when an existing container is reused
+// (See WithName) but its services don't match the requested services.
+type ReusePolicy int
+
+const (
+    // ReuseFail returns an error unless the container has exactly the
+    // requested services.  This is the default.
+    ReuseFail ReusePolicy = iota
+    // ReuseRecreate removes the container and starts a new one with the
+    // requested services.
+    ReuseRecreate
+    // ReuseSuperset reuses the container as long as it has at least the
+    // requested services.
+    ReuseSuperset
+)
+
+// containerServices returns the names of the services a container was started
+// with, based on its SERVICES environment variable.  all is true when every
+// service is running because SERVICES wasn't given.
+func containerServices(container *docker.Container) (names []string, all bool) {
+    if container.Config == nil {
+        return nil, true
+    }
+
+    for _, env := range container.Config.Env {
+        if !strings.HasPrefix(env, "SERVICES=") {
+            continue
+        }
+        for _, service := range strings.Split(strings.TrimPrefix(env, "SERVICES="), ",") {
+            // Services may be given as name or name:port.
+            name := strings.TrimSpace(strings.Split(service, ":")[0])
+            if name != "" {
+                names = append(names, name)
+            }
+        }
+    }
+
+    sort.Strings(names)
+    return names, len(names) == 0
+}
+
+// reconcileLocalstack decides whether an existing container can be reused for
+// the requested services.  It returns nil when a new container should be started.
+func reconcileLocalstack(ctx context.Context, wrapper DockerWrapper, resource *dockertest.Resource, services *LocalstackServiceCollection, policy ReusePolicy) (*dockertest.Resource, error) {
+
+    existing, all := containerServices(resource.Container)
+
+    var missing []string
+    for _, service := range *services {
+        if !all && !contains(existing, service.Name) {
+            missing = append(missing, service.Name)
+        }
+    }
+    exact := !all && len(missing) == 0 && len(existing) == len(*services)
+
+    if exact || (policy == ReuseSuperset && len(missing) == 0) {
+        return resource, nil
+    }
+
+    if policy == ReuseRecreate {
+        if err := wrapper.RemoveContainer(ctx, docker.RemoveContainerOptions {
+            ID: resource.Container.ID,
+            Force: true,
+            RemoveVolumes: true,
+        }); err != nil {
+            return nil, errors.New(fmt.Sprintf("Unable to remove container %s: %s", resource.Container.ID, err))
+        }
+        return nil, nil
+    }
+
+    provided := strings.Join(existing, ",")
+    if all {
+        provided = "all services"
+    }
+    return nil, errors.New(fmt.Sprintf("Container %s provides %s but %s was requested", resource.Container.ID, provided, services.GetServiceMap()))
+}
+
+func contains(values []string, value string) bool {
+    for _, v := range values {
+        if v == value {
+            return true
+        }
+    }
+    return false
+}
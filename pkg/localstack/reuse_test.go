@@ -0,0 +1,191 @@
+package localstack
+
+import (
+    "context"
+    "fmt"
+    "testing"
+    "github.com/ory/dockertest"
+    "github.com/ory/dockertest/docker"
+    "github.com/mitchelldavis/go_localstack/pkg/mock_localstack"
+    "github.com/golang/mock/gomock"
+)
+
+// getLocalstack_FoundWithEnv returns a wrapper that finds a single container
+// started with the environment given.
+func getLocalstack_FoundWithEnv(ctrl *gomock.Controller, env ...string) *mock_localstack.MockDockerWrapper {
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+
+    m.
+    EXPECT().
+    ListContainers(gomock.Any(), gomock.Any()).
+    Times(1).
+    Return([]docker.APIContainers {
+        docker.APIContainers {
+            ID: "DummyID",
+            Image: fmt.Sprintf("%s:%s", Localstack_Repository, Localstack_Tag),
+            Names: []string {fmt.Sprintf("/%s", Localstack_Name)},
+        },
+    }, nil)
+
+    m.
+    EXPECT().
+    InspectContainer(gomock.Any(), "DummyID").
+    Times(1).
+    Return(&docker.Container { ID: "DummyID", Config: &docker.Config { Env: env } }, nil)
+
+    return m
+}
+
+func Test_containerServices(t *testing.T) {
+    names, all := containerServices(&docker.Container { Config: &docker.Config { Env: []string {
+        "PATH=/usr/bin",
+        "SERVICES=sqs:4576,s3",
+    }}})
+
+    if all || len(names) != 2 || names[0] != "s3" || names[1] != "sqs" {
+        t.Errorf("The services were not parsed correctly.  Received %v", names)
+    }
+
+    names, all = containerServices(&docker.Container { Config: &docker.Config { Env: []string {
+        "PATH=/usr/bin",
+    }}})
+
+    if !all || len(names) != 0 {
+        t.Errorf("Without SERVICES every service should be running.  Received %v", names)
+    }
+}
+
+func Test_NewLocalstack_Reuse_MismatchFails(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    sqs, _ := NewLocalstackService("sqs")
+    s3, _ := NewLocalstackService("s3")
+    services := &LocalstackServiceCollection {
+        *sqs,
+        *s3,
+    }
+    m := getLocalstack_FoundWithEnv(ctrl, "SERVICES=sqs:4576")
+
+    m.
+    EXPECT().
+    RunWithOptions(gomock.Any(), gomock.Any()).
+    Times(0)
+
+    result, err := newLocalstack(context.Background(), services, m, testOptions...)
+
+    if result != nil {
+        t.Error("We were expecting the returned container to be nil.")
+    }
+
+    if err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+}
+
+func Test_NewLocalstack_Reuse_SupersetFailsByDefault(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    sqs, _ := NewLocalstackService("sqs")
+    services := &LocalstackServiceCollection {
+        *sqs,
+    }
+    m := getLocalstack_FoundWithEnv(ctrl, "SERVICES=sqs:4576,s3:4572")
+
+    result, err := newLocalstack(context.Background(), services, m, testOptions...)
+
+    if result != nil || err == nil {
+        t.Error("A superset should only be accepted with ReuseSuperset.")
+    }
+}
+
+func Test_NewLocalstack_Reuse_Superset(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    sqs, _ := NewLocalstackService("sqs")
+    services := &LocalstackServiceCollection {
+        *sqs,
+    }
+    m := getLocalstack_FoundWithEnv(ctrl, "SERVICES=sqs:4576,s3:4572")
+
+    m.
+    EXPECT().
+    Retry(gomock.Any(), gomock.Any()).
+    Times(1).
+    Return(nil)
+
+    result, err := newLocalstack(context.Background(), services, m, append(testOptions, WithReusePolicy(ReuseSuperset))...)
+
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if result.Resource.Container.ID != "DummyID" {
+        t.Error("The existing container should have been reused.")
+    }
+}
+
+func Test_NewLocalstack_Reuse_SupersetMissingService(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    sqs, _ := NewLocalstackService("sqs")
+    sns, _ := NewLocalstackService("sns")
+    services := &LocalstackServiceCollection {
+        *sqs,
+        *sns,
+    }
+    m := getLocalstack_FoundWithEnv(ctrl, "SERVICES=sqs:4576,s3:4572")
+
+    result, err := newLocalstack(context.Background(), services, m, append(testOptions, WithReusePolicy(ReuseSuperset))...)
+
+    if result != nil || err == nil {
+        t.Error("A container without sns should not be reused.")
+    }
+}
+
+func Test_NewLocalstack_Reuse_Recreate(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    sqs, _ := NewLocalstackService("sqs")
+    s3, _ := NewLocalstackService("s3")
+    services := &LocalstackServiceCollection {
+        *sqs,
+        *s3,
+    }
+    m := getLocalstack_FoundWithEnv(ctrl, "SERVICES=sqs:4576")
+    resource := &dockertest.Resource { Container: &docker.Container { ID: "NewID" } }
+
+    gomock.InOrder(
+        m.
+        EXPECT().
+        RemoveContainer(gomock.Any(), docker.RemoveContainerOptions { ID: "DummyID", Force: true, RemoveVolumes: true }).
+        Return(nil),
+        m.
+        EXPECT().
+        RunWithOptions(gomock.Any(), gomock.Any()).
+        Return(resource, nil),
+        m.
+        EXPECT().
+        Retry(gomock.Any(), gomock.Any()).
+        Return(nil),
+    )
+
+    result, err := newLocalstack(context.Background(), services, m, append(testOptions, WithReusePolicy(ReuseRecreate))...)
+
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if result.Resource != resource {
+        t.Error("A new container should have been started.")
+    }
+}
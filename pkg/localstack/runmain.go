@@ -0,0 +1,146 @@
+package localstack
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "os"
+    "os/exec"
+    "os/signal"
+    "syscall"
+    "testing"
+	"github.com/ory/dockertest"
+)
+
+// Localstack_ContainerEnv is set for the tests run by RunMain to the ID of the
+// container they should use.
+const Localstack_ContainerEnv string = "GO_LOCALSTACK_CONTAINER"
+
+// mainRunner is implemented by *testing.M.
+type mainRunner interface {
+    Run() int
+}
+
+// current is the Localstack started by RunMain.
+var current *Localstack
+
+// mainCommand returns the command that runs the tests for RunMain.  The tests
+// share the standard input and output of the process.
+var mainCommand = func() *exec.Cmd {
+    cmd := exec.Command(os.Args[0], os.Args[1:]...)
+    cmd.Stdin = os.Stdin
+    cmd.Stdout = os.Stdout
+    cmd.Stderr = os.Stderr
+    return cmd
+}
+
+// Current returns the Localstack started by RunMain, or nil outside of RunMain.
+func Current() *Localstack {
+    return current
+}
+
+// RunMain starts Localstack with the services and options given, runs the tests
+// and exits with their exit code.  It is meant to be called from TestMain, and
+// Localstack is available to the tests through Current.
+//
+//     func TestMain(m *testing.M) {
+//         services, _ := localstack.NewLocalstackServiceCollection("s3", "sqs")
+//         localstack.RunMain(m, services)
+//     }
+//
+// The container is always destroyed, even when a test panics or the tests are
+// interrupted with SIGINT or SIGTERM.  To make that possible, the tests are run
+// in a copy of the test binary while this process watches over the container.
+func RunMain(m *testing.M, services *LocalstackServiceCollection, opts ...Option) {
+    os.Exit(runMain(m, services, &_DockerWrapper{ }, opts...))
+}
+
+func runMain(m mainRunner, services *LocalstackServiceCollection, wrapper DockerWrapper, opts ...Option) int {
+    ctx := context.Background()
+
+    // We are the copy of the test binary, so run the tests.
+    if id := os.Getenv(Localstack_ContainerEnv); id != "" {
+        ls, err := attachLocalstack(ctx, services, wrapper, id, opts...)
+        if err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            return 1
+        }
+        current = ls
+        return m.Run()
+    }
+
+    signals := make(chan os.Signal, 2)
+    signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+    defer signal.Stop(signals)
+
+    ls, err := newLocalstack(ctx, services, wrapper, opts...)
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "Unable to create the Localstack instance: %s\n", err)
+        return 1
+    }
+    defer func() {
+        if err := ls.Destroy(); err != nil {
+            fmt.Fprintln(os.Stderr, err)
+        }
+    }()
+
+    cmd := mainCommand()
+    cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", Localstack_ContainerEnv, ls.Resource.Container.ID))
+    if err := cmd.Start(); err != nil {
+        fmt.Fprintf(os.Stderr, "Unable to run the tests: %s\n", err)
+        return 1
+    }
+
+    done := make(chan error, 1)
+    go func() {
+        done <- cmd.Wait()
+    }()
+
+    var received os.Signal
+    for {
+        select {
+        case <-done:
+            if received != nil {
+                if sig, ok := received.(syscall.Signal); ok {
+                    return 128 + int(sig)
+                }
+                return 1
+            }
+            if code := cmd.ProcessState.ExitCode(); code >= 0 {
+                return code
+            }
+            return 1
+        case sig := <-signals:
+            // Give the tests a chance to stop on their own the first time.
+            if received != nil || cmd.Process.Signal(sig) != nil {
+                cmd.Process.Kill()
+            }
+            received = sig
+        }
+    }
+}
+
+// attachLocalstack returns a Localstack for the container already started by RunMain.
+func attachLocalstack(ctx context.Context, services *LocalstackServiceCollection, wrapper DockerWrapper, id string, opts ...Option) (*Localstack, error) {
+    options := newLocalstackOptions(opts...)
+
+    container, err := wrapper.InspectContainer(ctx, id)
+    if err != nil {
+        return nil, errors.New(fmt.Sprintf("Unable to inspect container %s: %s", id, err))
+    }
+
+    result := &Localstack {
+        Resource: &dockertest.Resource { Container: container },
+        Services: services,
+        wrapper: wrapper,
+        portMode: options.portMode,
+        options: options,
+    }
+    if result.portMode == PortModeAuto {
+        result.portMode = result.detectPortMode(ctx, options.tag)
+    }
+    if err := result.startFaultProxy(); err != nil {
+        return nil, err
+    }
+    return result, nil
+}
@@ -0,0 +1,141 @@
+package localstack
+
+import (
+    "context"
+    "io/ioutil"
+    "os"
+    "os/exec"
+    "testing"
+    "github.com/ory/dockertest"
+    "github.com/ory/dockertest/docker"
+    "github.com/mitchelldavis/go_localstack/pkg/mock_localstack"
+    "github.com/golang/mock/gomock"
+)
+
+// runMainHelperEnv tells Test_RunMain_HelperProcess how to behave when it
+// is run as the tests of RunMain.
+const runMainHelperEnv string = "GO_LOCALSTACK_HELPER"
+
+type mainFunc func() int
+
+func (f mainFunc) Run() int {
+    return f()
+}
+
+// Test_RunMain_HelperProcess isn't a real test.  It stands in for the tests run by RunMain.
+func Test_RunMain_HelperProcess(t *testing.T) {
+    switch os.Getenv(runMainHelperEnv) {
+    case "":
+        return
+    case "panic":
+        panic("Dummy Panic")
+    default:
+        if os.Getenv(Localstack_ContainerEnv) != "DummyID" {
+            os.Exit(100)
+        }
+        os.Exit(3)
+    }
+}
+
+func runMainHelper(t *testing.T, mode string, ctrl *gomock.Controller) (*mock_localstack.MockDockerWrapper, func()) {
+    os.Setenv(runMainHelperEnv, mode)
+    original := mainCommand
+    mainCommand = func() *exec.Cmd {
+        cmd := exec.Command(os.Args[0], "-test.run=^Test_RunMain_HelperProcess$")
+        cmd.Stdout = ioutil.Discard
+        cmd.Stderr = ioutil.Discard
+        return cmd
+    }
+
+    sqs, _ := NewLocalstackService("sqs")
+    m := getLocalstack_Empty(&LocalstackServiceCollection { *sqs }, ctrl)
+
+    m.
+    EXPECT().
+    RunWithOptions(gomock.Any(), gomock.Any()).
+    Return(&dockertest.Resource { Container: &docker.Container { ID: "DummyID" } }, nil)
+
+    m.
+    EXPECT().
+    Retry(gomock.Any(), gomock.Any()).
+    Return(nil)
+
+    m.
+    EXPECT().
+    RemoveContainer(gomock.Any(), gomock.Any()).
+    Times(1).
+    Do(func(ctx context.Context, opts docker.RemoveContainerOptions) {
+        if opts.ID != "DummyID" {
+            t.Errorf("The wrong container was removed.  Received %s", opts.ID)
+        }
+    }).
+    Return(nil)
+
+    return m, func() {
+        os.Unsetenv(runMainHelperEnv)
+        mainCommand = original
+    }
+}
+
+func Test_RunMain(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    m, restore := runMainHelper(t, "exit", ctrl)
+    defer restore()
+
+    sqs, _ := NewLocalstackService("sqs")
+    code := runMain(nil, &LocalstackServiceCollection { *sqs }, m, WithName(Localstack_Name), WithPortMode(PortModeEdge))
+    if code != 3 {
+        t.Errorf("The exit code of the tests should be returned.  Received %d", code)
+    }
+}
+
+func Test_RunMain_Panic(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    m, restore := runMainHelper(t, "panic", ctrl)
+    defer restore()
+
+    sqs, _ := NewLocalstackService("sqs")
+    code := runMain(nil, &LocalstackServiceCollection { *sqs }, m, WithName(Localstack_Name), WithPortMode(PortModeEdge))
+    if code == 0 {
+        t.Error("A panic should fail the tests.")
+    }
+}
+
+func Test_RunMain_Attach(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    os.Setenv(Localstack_ContainerEnv, "DummyID")
+    defer os.Unsetenv(Localstack_ContainerEnv)
+    defer func() { current = nil }()
+
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+    m.
+    EXPECT().
+    InspectContainer(gomock.Any(), "DummyID").
+    Return(&docker.Container { ID: "DummyID" }, nil)
+
+    m.
+    EXPECT().
+    RunWithOptions(gomock.Any(), gomock.Any()).
+    Times(0)
+
+    sqs, _ := NewLocalstackService("sqs")
+    code := runMain(mainFunc(func() int {
+        if Current() == nil || Current().Resource.Container.ID != "DummyID" {
+            t.Error("The tests should use the container started by RunMain.")
+        }
+        return 5
+    }), &LocalstackServiceCollection { *sqs }, m, WithPortMode(PortModeEdge))
+
+    if code != 5 {
+        t.Errorf("The exit code of the tests should be returned.  Received %d", code)
+    }
+}
@@ -0,0 +1,55 @@
+package localstack
+
+import (
+    "context"
+    "errors"
+    "strings"
+    "testing"
+    awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func Test_EndpointResolverV2(t *testing.T) {
+    resolver := strictLocalstack().EndpointResolverV2()
+
+    ep, err := resolver.ResolveEndpoint("S3", "us-west-2")
+    if err != nil || ep.URL != "http://1.0.0.0:9572" || !ep.HostnameImmutable {
+        t.Errorf("The return endpoint was not correct.  Received %+v", ep)
+    }
+
+    _, err = resolver.ResolveEndpoint("SQS", "us-west-2")
+    if err == nil || !strings.Contains(err.Error(), "sqs") {
+        t.Errorf("The error should name the service that wasn't requested.  Received %v", err)
+    }
+
+    var notFound *awsv2.EndpointNotFoundError
+    if errors.As(err, &notFound) {
+        t.Error("A strict resolver should not let the client fall back to AWS.")
+    }
+}
+
+func Test_EndpointResolverV2_EndpointFallback(t *testing.T) {
+    resolver := strictLocalstack(WithEndpointFallback()).EndpointResolverV2()
+
+    _, err := resolver.ResolveEndpoint("SQS", "us-west-2")
+    var notFound *awsv2.EndpointNotFoundError
+    if !errors.As(err, &notFound) {
+        t.Errorf("The client should fall back to AWS.  Received %v", err)
+    }
+}
+
+func Test_CreateAWSConfig(t *testing.T) {
+    config := strictLocalstack().CreateAWSConfig()
+
+    if config.Region != Localstack_Region {
+        t.Errorf("The region was not correct.  Received %s", config.Region)
+    }
+
+    credentials, err := config.Credentials.Retrieve(context.Background())
+    if err != nil || credentials.AccessKeyID != "a" {
+        t.Errorf("The dummy credentials should be used.  Received %+v", credentials)
+    }
+
+    if ep, _ := config.EndpointResolverWithOptions.ResolveEndpoint("S3", "us-east-1"); ep.URL != "http://1.0.0.0:9572" {
+        t.Errorf("The config should resolve to Localstack.  Received %s", ep.URL)
+    }
+}
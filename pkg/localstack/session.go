@@ -0,0 +1,92 @@
+package localstack
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/credentials"
+    "github.com/aws/aws-sdk-go/aws/request"
+    "github.com/aws/aws-sdk-go/aws/session"
+)
+
+// Localstack_Region is the region used by sessions unless another one is given.
+const Localstack_Region string = "us-east-1"
+
+// SessionOptions configures the sessions created by CreateAWSSessionWithOptions.
+// Every field is optional.
+type SessionOptions struct {
+    // Region is the AWS region.  The default is Localstack_Region.
+    Region string
+    // Credentials are the credentials requests are signed with.  Localstack
+    // doesn't check them, so the default is a set of static dummy credentials.
+    Credentials *credentials.Credentials
+    // MaxRetries is the number of times a failed request is retried.
+    // The default is the SDK's default.
+    MaxRetries *int
+    // LogLevel sets what the SDK logs.  (I.E. aws.LogDebugWithHTTPBody)
+    LogLevel aws.LogLevelType
+    // Logger is where the SDK logs to.  The default is the SDK's default.
+    Logger aws.Logger
+    // HTTPClient sends the requests.  The default is the SDK's default.
+    // With WithTLS, a copy of it whose transport trusts Localstack's CA is used.
+    HTTPClient *http.Client
+    // Handlers are called with the handlers of the session so they can be
+    // customized.  (I.E. to add a handler to Handlers.Send)
+    Handlers []func(*request.Handlers)
+}
+
+// CreateAWSSessionWithOptions creates a session that routes AWS SDK traffic to
+// Localstack like CreateAWSSession, configured by the options given.  Localstack
+// is always the endpoint resolver, and the recorders given with WithRecorder are
+// always installed.
+func (l *Localstack) CreateAWSSessionWithOptions(options SessionOptions) (*session.Session, error) {
+    _, tls := l.CACertificate()
+    config := aws.Config {
+        Region: aws.String(Localstack_Region),
+        EndpointResolver: l.resolver(),
+        DisableSSL: aws.Bool(!tls),
+        S3ForcePathStyle: aws.Bool(true),
+        Credentials: credentials.NewStaticCredentials("a", "b", "c"),
+        MaxRetries: options.MaxRetries,
+        LogLevel: aws.LogLevel(options.LogLevel),
+        Logger: options.Logger,
+        HTTPClient: options.HTTPClient,
+    }
+    if options.Region != "" {
+        config.Region = aws.String(options.Region)
+    }
+    if options.Credentials != nil {
+        config.Credentials = options.Credentials
+    }
+
+    if tls {
+        // The SDK replaces the CA of the client it's given when AWS_CA_BUNDLE
+        // is set, so the client is only set once the session is created.
+        config.HTTPClient = &http.Client{ }
+    }
+
+    sess, err := session.NewSessionWithOptions(session.Options { Config: config })
+    if err != nil {
+        return nil, errors.New(fmt.Sprintf("Unable to create an AWS session: %s", err))
+    }
+
+    if tls {
+        client, err := l.trustingClient(options.HTTPClient)
+        if err != nil {
+            return nil, err
+        }
+        sess.Config.HTTPClient = client
+    }
+
+    l.installStrictHandler(sess)
+    if l.options != nil {
+        for _, rec := range l.options.recorders {
+            rec.Install(&sess.Handlers)
+        }
+    }
+    for _, handlers := range options.Handlers {
+        handlers(&sess.Handlers)
+    }
+    return sess, nil
+}
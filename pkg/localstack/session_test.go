@@ -0,0 +1,72 @@
+package localstack
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "github.com/ory/dockertest"
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/credentials"
+    "github.com/aws/aws-sdk-go/aws/request"
+    "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+func Test_CreateAWSSessionWithOptions(t *testing.T) {
+    var authorization string
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        authorization = r.Header.Get("Authorization")
+        w.Write([]byte(`<ListQueuesResponse><ListQueuesResult></ListQueuesResult></ListQueuesResponse>`))
+    }))
+    defer server.Close()
+
+    sqsService, _ := NewLocalstackService("sqs")
+    ls := &Localstack {
+        Resource: &dockertest.Resource { Container: healthContainer(server) },
+        Services: &LocalstackServiceCollection { *sqsService },
+        portMode: PortModeEdge,
+    }
+
+    sent := 0
+    client := &http.Client{ }
+    sess, err := ls.CreateAWSSessionWithOptions(SessionOptions {
+        Region: "eu-west-1",
+        Credentials: credentials.NewStaticCredentials("key", "secret", ""),
+        MaxRetries: aws.Int(7),
+        HTTPClient: client,
+        Handlers: []func(*request.Handlers) {
+            func(handlers *request.Handlers) {
+                handlers.Send.PushFront(func(r *request.Request) { sent++ })
+            },
+        },
+    })
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if *sess.Config.Region != "eu-west-1" || *sess.Config.MaxRetries != 7 || sess.Config.HTTPClient != client {
+        t.Errorf("The session was not configured by the options.  Received %+v", sess.Config)
+    }
+
+    if _, err := sqs.New(sess).ListQueues(&sqs.ListQueuesInput{ }); err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if sent != 1 {
+        t.Errorf("The custom handlers should be installed.  Called %d times", sent)
+    }
+    if !strings.Contains(authorization, "Credential=key/") || !strings.Contains(authorization, "/eu-west-1/sqs/") {
+        t.Errorf("Requests should be signed with the credentials and region given.  Received %s", authorization)
+    }
+}
+
+func Test_CreateAWSSessionWithOptions_Defaults(t *testing.T) {
+    sess, err := strictLocalstack().CreateAWSSessionWithOptions(SessionOptions{ })
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if *sess.Config.Region != Localstack_Region || !*sess.Config.S3ForcePathStyle {
+        t.Errorf("The session should be configured for Localstack.  Received %+v", sess.Config)
+    }
+}
@@ -0,0 +1,200 @@
+package localstack
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+    "github.com/ory/dockertest"
+)
+
+// Localstack_SharedLabel is the docker label that holds the key of a shared
+// Localstack container.  (See WithShared)
+const Localstack_SharedLabel string = "go_localstack.shared"
+
+// sharedHolder is a single reference to a shared Localstack container.
+// The holders of a container are recorded in a file in the temp directory
+// of the host, which is guarded by a lock file so separate test processes
+// can share it.
+type sharedHolder struct {
+    key string
+    id string
+    dir string
+}
+
+// sharedKey identifies a shared container by its image, its services and the
+// options that change how the container is created, so holders that need a
+// different container never share one.
+func sharedKey(services *LocalstackServiceCollection, options *localstackOptions) string {
+    var names []string
+    for _, service := range *services {
+        names = append(names, service.Name)
+    }
+    sort.Strings(names)
+
+    config := []string {
+        fmt.Sprintf("%s:%s", options.repository, options.tag),
+        strings.Join(names, ","),
+        strings.Join(options.env, ","),
+        strings.Join(options.mounts, ","),
+        fmt.Sprintf("%d", options.portMode),
+        options.dataDir,
+        options.snapshot,
+        strconv.FormatBool(options.persist),
+        strconv.FormatBool(options.tls),
+    }
+    sum := sha256.Sum256([]byte(strings.Join(config, "|")))
+    return hex.EncodeToString(sum[:])[:16]
+}
+
+func newSharedHolder(services *LocalstackServiceCollection, options *localstackOptions) (*sharedHolder, error) {
+    random := make([]byte, 8)
+    if _, err := rand.Read(random); err != nil {
+        return nil, errors.New(fmt.Sprintf("Unable to create a holder id: %s", err))
+    }
+
+    return &sharedHolder {
+        key: sharedKey(services, options),
+        id: fmt.Sprintf("%d-%s", os.Getpid(), hex.EncodeToString(random)),
+        dir: os.TempDir(),
+    }, nil
+}
+
+// name is the name of the shared container.
+func (h *sharedHolder) name() string {
+    return fmt.Sprintf("go_localstack_%s", h.key)
+}
+
+func (h *sharedHolder) lockPath() string {
+    return filepath.Join(h.dir, h.name() + ".lock")
+}
+
+func (h *sharedHolder) holdersPath() string {
+    return filepath.Join(h.dir, h.name() + ".holders")
+}
+
+// lock acquires the host lock for the shared container.  The function
+// returned releases it.  The lock is held on the lock file by the system,
+// so it is released even when the process holding it is killed.
+func (h *sharedHolder) lock(ctx context.Context) (func(), error) {
+    file, err := os.OpenFile(h.lockPath(), os.O_CREATE | os.O_RDWR, 0644)
+    if err != nil {
+        return nil, errors.New(fmt.Sprintf("Unable to create lock file %s: %s", h.lockPath(), err))
+    }
+
+    for {
+        locked, err := tryLockFile(file)
+        if err != nil {
+            file.Close()
+            return nil, errors.New(fmt.Sprintf("Unable to lock %s: %s", h.lockPath(), err))
+        }
+        if locked {
+            return func() {
+                unlockFile(file)
+                file.Close()
+            }, nil
+        }
+
+        select {
+        case <-ctx.Done():
+            file.Close()
+            return nil, errors.New(fmt.Sprintf("Unable to acquire lock file %s: %s", h.lockPath(), ctx.Err()))
+        case <-time.After(time.Millisecond * 100):
+        }
+    }
+}
+
+// holders returns the holders recorded for the shared container whose
+// processes are still running.
+func (h *sharedHolder) holders() ([]string, error) {
+    content, err := ioutil.ReadFile(h.holdersPath())
+    if os.IsNotExist(err) {
+        return nil, nil
+    } else if err != nil {
+        return nil, errors.New(fmt.Sprintf("Unable to read %s: %s", h.holdersPath(), err))
+    }
+
+    var holders []string
+    for _, holder := range strings.Fields(string(content)) {
+        if sessionAlive(holder) {
+            holders = append(holders, holder)
+        }
+    }
+    return holders, nil
+}
+
+func (h *sharedHolder) writeHolders(holders []string) error {
+    if len(holders) == 0 {
+        if err := os.Remove(h.holdersPath()); err != nil && !os.IsNotExist(err) {
+            return errors.New(fmt.Sprintf("Unable to remove %s: %s", h.holdersPath(), err))
+        }
+        return nil
+    }
+    if err := ioutil.WriteFile(h.holdersPath(), []byte(strings.Join(holders, "\n") + "\n"), 0644); err != nil {
+        return errors.New(fmt.Sprintf("Unable to write %s: %s", h.holdersPath(), err))
+    }
+    return nil
+}
+
+// acquire finds or starts the shared container using the function given
+// and records this holder.
+func (h *sharedHolder) acquire(ctx context.Context, start func() (*dockertest.Resource, error)) (*dockertest.Resource, error) {
+    unlock, err := h.lock(ctx)
+    if err != nil {
+        return nil, err
+    }
+    defer unlock()
+
+    holders, err := h.holders()
+    if err != nil {
+        return nil, err
+    }
+
+    resource, err := start()
+    if err != nil {
+        return nil, err
+    }
+
+    if err := h.writeHolders(append(holders, h.id)); err != nil {
+        return nil, err
+    }
+    return resource, nil
+}
+
+// release removes this holder.  When it was the last holder, the
+// container is removed with the function given.
+func (h *sharedHolder) release(ctx context.Context, remove func() error) error {
+    unlock, err := h.lock(ctx)
+    if err != nil {
+        return err
+    }
+    defer unlock()
+
+    holders, err := h.holders()
+    if err != nil {
+        return err
+    }
+
+    var remaining []string
+    for _, holder := range holders {
+        if holder != h.id {
+            remaining = append(remaining, holder)
+        }
+    }
+
+    if len(remaining) == 0 {
+        if err := remove(); err != nil {
+            return err
+        }
+    }
+    return h.writeHolders(remaining)
+}
@@ -0,0 +1,229 @@
+package localstack
+
+import (
+    "context"
+    "io/ioutil"
+    "os"
+    "sync"
+    "testing"
+    "time"
+    "github.com/ory/dockertest"
+    "github.com/ory/dockertest/docker"
+    "github.com/golang/mock/gomock"
+)
+
+func testSharedHolder(t *testing.T, dir string) *sharedHolder {
+    sqs, _ := NewLocalstackService("sqs")
+    holder, err := newSharedHolder(&LocalstackServiceCollection { *sqs }, newLocalstackOptions(WithTag(Localstack_Tag)))
+    if err != nil {
+        t.Fatalf("Unable to create a holder: %s", err)
+    }
+    holder.dir = dir
+    return holder
+}
+
+func Test_sharedKey(t *testing.T) {
+    sqs, _ := NewLocalstackService("sqs")
+    s3, _ := NewLocalstackService("s3")
+
+    options := newLocalstackOptions(WithTag(Localstack_Tag))
+
+    lhs := sharedKey(&LocalstackServiceCollection { *sqs, *s3 }, options)
+    rhs := sharedKey(&LocalstackServiceCollection { *s3, *sqs }, options)
+    if lhs != rhs {
+        t.Error("The key should not depend on the order of the services.")
+    }
+
+    if lhs == sharedKey(&LocalstackServiceCollection { *sqs, *s3 }, newLocalstackOptions(WithTag("latest"))) {
+        t.Error("The key should depend on the tag.")
+    }
+
+    if lhs == sharedKey(&LocalstackServiceCollection { *sqs }, options) {
+        t.Error("The key should depend on the services.")
+    }
+
+    differentOptions := map[string]Option {
+        "WithEnv": WithEnv("DEBUG", "1"),
+        "WithMounts": WithMounts("/tmp:/tmp"),
+        "WithPortMode": WithPortMode(PortModeEdge),
+        "WithDataDir": WithDataDir("/tmp/data"),
+        "FromSnapshot": FromSnapshot("/tmp/snapshot"),
+        "WithPersistence": WithPersistence(),
+        "WithTLS": WithTLS(),
+    }
+    for name, option := range differentOptions {
+        if lhs == sharedKey(&LocalstackServiceCollection { *sqs, *s3 }, newLocalstackOptions(WithTag(Localstack_Tag), option)) {
+            t.Errorf("The key should depend on %s.", name)
+        }
+    }
+}
+
+func Test_sharedHolder_LastHolderRemoves(t *testing.T) {
+    dir, _ := ioutil.TempDir("", "go_localstack")
+    defer os.RemoveAll(dir)
+
+    first := testSharedHolder(t, dir)
+    second := testSharedHolder(t, dir)
+    resource := &dockertest.Resource{ }
+    start := func() (*dockertest.Resource, error) { return resource, nil }
+
+    if r, err := first.acquire(context.Background(), start); err != nil || r != resource {
+        t.Fatalf("Unable to acquire the first holder: %s", err)
+    }
+    if _, err := second.acquire(context.Background(), start); err != nil {
+        t.Fatalf("Unable to acquire the second holder: %s", err)
+    }
+
+    removed := 0
+    remove := func() error {
+        removed++
+        return nil
+    }
+
+    if err := first.release(context.Background(), remove); err != nil {
+        t.Fatalf("Unable to release the first holder: %s", err)
+    }
+    if removed != 0 {
+        t.Error("The container should not be removed while it still has a holder.")
+    }
+
+    if err := second.release(context.Background(), remove); err != nil {
+        t.Fatalf("Unable to release the second holder: %s", err)
+    }
+    if removed != 1 {
+        t.Error("The container should be removed by the last holder.")
+    }
+
+    if _, err := os.Stat(first.holdersPath()); !os.IsNotExist(err) {
+        t.Error("The holders file should be removed with the container.")
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+    defer cancel()
+    unlock, err := first.lock(ctx)
+    if err != nil {
+        t.Fatalf("The lock should be released: %s", err)
+    }
+    unlock()
+}
+
+func Test_sharedHolder_CompetingLocks(t *testing.T) {
+    dir, _ := ioutil.TempDir("", "go_localstack")
+    defer os.RemoveAll(dir)
+
+    // A lock file left behind by a process that no longer exists.
+    ioutil.WriteFile(testSharedHolder(t, dir).lockPath(), []byte("2147483646"), 0644)
+
+    var mutex sync.Mutex
+    holding, overlaps := 0, 0
+    var wg sync.WaitGroup
+    for i := 0; i < 2; i++ {
+        holder := testSharedHolder(t, dir)
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for j := 0; j < 5; j++ {
+                unlock, err := holder.lock(context.Background())
+                if err != nil {
+                    t.Errorf("Unable to acquire the lock: %s", err)
+                    return
+                }
+
+                mutex.Lock()
+                holding++
+                if holding > 1 {
+                    overlaps++
+                }
+                mutex.Unlock()
+
+                time.Sleep(time.Millisecond * 10)
+
+                mutex.Lock()
+                holding--
+                mutex.Unlock()
+                unlock()
+            }
+        }()
+    }
+    wg.Wait()
+
+    if overlaps > 0 {
+        t.Errorf("Only one holder should hold the lock at a time.  Overlapped %d times", overlaps)
+    }
+}
+
+func Test_sharedHolder_DeadHoldersArePruned(t *testing.T) {
+    dir, _ := ioutil.TempDir("", "go_localstack")
+    defer os.RemoveAll(dir)
+
+    holder := testSharedHolder(t, dir)
+    ioutil.WriteFile(holder.holdersPath(), []byte("2147483646-0000\n"), 0644)
+    // A lock left behind by a process that no longer exists.
+    ioutil.WriteFile(holder.lockPath(), []byte("2147483646"), 0644)
+
+    if _, err := holder.acquire(context.Background(), func() (*dockertest.Resource, error) { return nil, nil }); err != nil {
+        t.Fatalf("Unable to acquire the holder: %s", err)
+    }
+
+    removed := false
+    if err := holder.release(context.Background(), func() error { removed = true; return nil }); err != nil {
+        t.Fatalf("Unable to release the holder: %s", err)
+    }
+
+    if !removed {
+        t.Error("A holder whose process is gone should not keep the container alive.")
+    }
+}
+
+func Test_NewLocalstack_Shared(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    dir, _ := ioutil.TempDir("", "go_localstack")
+    defer os.RemoveAll(dir)
+    tmpdir := os.Getenv("TMPDIR")
+    os.Setenv("TMPDIR", dir)
+    defer os.Setenv("TMPDIR", tmpdir)
+
+    sqs, _ := NewLocalstackService("sqs")
+    services := &LocalstackServiceCollection {
+        *sqs,
+    }
+    key := sharedKey(services, newLocalstackOptions(WithRepository(Localstack_Repository), WithTag(Localstack_Tag)))
+    m := getLocalstack_Empty(services, ctrl)
+
+    m.
+    EXPECT().
+    RunWithOptions(gomock.Any(), gomock.Any()).
+    Times(1).
+    Do(func(ctx context.Context, opts *dockertest.RunOptions, hcOpts ...func(*docker.HostConfig)) {
+        if opts.Name != "go_localstack_" + key {
+            t.Errorf("The container should be named after the key.  Received %s", opts.Name)
+        }
+        if opts.Labels[Localstack_SharedLabel] != key {
+            t.Errorf("The container should be labeled with the key.  Received %v", opts.Labels)
+        }
+    }).
+    Return(&dockertest.Resource { Container: &docker.Container { ID: "DummyID" } }, nil)
+
+    m.
+    EXPECT().
+    Retry(gomock.Any(), gomock.Any()).
+    Times(1).
+    Return(nil)
+
+    m.
+    EXPECT().
+    RemoveContainer(gomock.Any(), gomock.Any()).
+    Times(1).
+    Return(nil)
+
+    result, err := newLocalstack(context.Background(), services, m, WithRepository(Localstack_Repository), WithTag(Localstack_Tag), WithShared())
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if err := result.Destroy(); err != nil {
+        t.Errorf("We were expecting the returned error to be nil: %s", err)
+    }
+}
@@ -0,0 +1,163 @@
+package localstack
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "strings"
+	"github.com/ory/dockertest/docker"
+)
+
+// Localstack_DataDir is where Localstack persists its state inside the container.
+// (See WithDataDir)
+const Localstack_DataDir string = "/tmp/localstack/data"
+
+// snapshotPrefix names the temporary data directories created by FromSnapshot.
+const snapshotPrefix string = "go_localstack_snapshot_"
+
+// prepareDataDir returns the host directory to mount at Localstack_DataDir, if any.
+// A snapshot is copied into a new temporary directory so it can be reused.
+func prepareDataDir(options *localstackOptions) (string, error) {
+    if options.snapshot == "" {
+        return options.dataDir, nil
+    }
+
+    dir, err := ioutil.TempDir("", snapshotPrefix)
+    if err != nil {
+        return "", errors.New(fmt.Sprintf("Unable to create a data directory: %s", err))
+    }
+    if err := copyDir(options.snapshot, dir); err != nil {
+        os.RemoveAll(dir)
+        return "", errors.New(fmt.Sprintf("Unable to restore snapshot %s: %s", options.snapshot, err))
+    }
+    return dir, nil
+}
+
+// dataDir returns the host directory mounted at Localstack_DataDir.
+func (ls *Localstack) dataDir() (string, bool) {
+    if ls.Resource == nil || ls.Resource.Container == nil {
+        return "", false
+    }
+    for _, mount := range ls.Resource.Container.Mounts {
+        if mount.Destination == Localstack_DataDir {
+            return mount.Source, true
+        }
+    }
+    return "", false
+}
+
+// removeSnapshotDataDir removes the temporary data directory created by FromSnapshot.
+// The mount is used instead of the options so the directory is removed by
+// whoever removes the container.  (See WithShared)
+func (ls *Localstack) removeSnapshotDataDir() error {
+    dir, ok := ls.dataDir()
+    if !ok || !strings.HasPrefix(filepath.Base(dir), snapshotPrefix) {
+        return nil
+    }
+    if err := os.RemoveAll(dir); err != nil {
+        return errors.New(fmt.Sprintf("Unable to remove data directory %s: %s", dir, err))
+    }
+    return nil
+}
+
+// Snapshot saves the state of Localstack into the directory given, which is
+// created if needed.  A new Localstack can be started from the snapshot with
+// FromSnapshot.  The container is paused while the state is copied.
+// NOTE:  Localstack must have been started with WithDataDir or FromSnapshot,
+// and the version of Localstack used must support DATA_DIR.
+func (ls *Localstack) Snapshot(dir string) error {
+    return ls.SnapshotContext(context.Background(), dir)
+}
+
+// SnapshotContext is Snapshot with a context that can be used to cancel the call.
+func (ls *Localstack) SnapshotContext(ctx context.Context, dir string) error {
+    source, ok := ls.dataDir()
+    if !ok {
+        return errors.New("Unable to snapshot Localstack: it wasn't started with a data directory (See WithDataDir)")
+    }
+
+    if err := ls.PauseContext(ctx); err != nil {
+        return err
+    }
+    copyErr := copyDir(source, dir)
+    // The container is always unpaused, even when the context is done, so
+    // it isn't left frozen.
+    if err := ls.UnpauseContext(context.Background()); err != nil {
+        return err
+    }
+    if copyErr != nil {
+        return errors.New(fmt.Sprintf("Unable to snapshot Localstack: %s", copyErr))
+    }
+    return nil
+}
+
+// copyDir copies the files in src into dst, creating dst if needed.
+func copyDir(src, dst string) error {
+    return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+        if err != nil {
+            return err
+        }
+        rel, err := filepath.Rel(src, path)
+        if err != nil {
+            return err
+        }
+        target := filepath.Join(dst, rel)
+
+        if info.IsDir() {
+            return os.MkdirAll(target, 0755)
+        }
+        if !info.Mode().IsRegular() {
+            return nil
+        }
+        return copyFile(path, target, info.Mode())
+    })
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+    in, err := os.Open(src)
+    if err != nil {
+        return err
+    }
+    defer in.Close()
+
+    out, err := os.OpenFile(dst, os.O_WRONLY | os.O_CREATE | os.O_TRUNC, mode.Perm())
+    if err != nil {
+        return err
+    }
+    if _, err := io.Copy(out, in); err != nil {
+        out.Close()
+        return err
+    }
+    return out.Close()
+}
+
+// CommitImage saves the Localstack container, including its state, as the
+// image repository:tag.  A new Localstack can be started from the image with
+// NewSpecificLocalstack or WithRepository and WithTag.
+// NOTE:  Docker only commits the container filesystem, so Localstack must have
+// been started with WithPersistence.  State in a data directory mount (See
+// WithDataDir) isn't captured; use Snapshot instead.
+func (ls *Localstack) CommitImage(repository, tag string) error {
+    return ls.CommitImageContext(context.Background(), repository, tag)
+}
+
+// CommitImageContext is CommitImage with a context that can be used to cancel the call.
+func (ls *Localstack) CommitImageContext(ctx context.Context, repository, tag string) error {
+    if dir, ok := ls.dataDir(); ok {
+        return errors.New(fmt.Sprintf("Unable to commit Localstack: its state is in the data directory %s (See Snapshot)", dir))
+    }
+
+    if _, err := ls.dockerWrapper().CommitContainer(ctx, docker.CommitContainerOptions {
+        Container: ls.Resource.Container.ID,
+        Repository: repository,
+        Tag: tag,
+        Message: "Committed by go_localstack",
+    }); err != nil {
+        return errors.New(fmt.Sprintf("Unable to commit container %s to %s:%s: %s", ls.Resource.Container.ID, repository, tag, err))
+    }
+    return nil
+}
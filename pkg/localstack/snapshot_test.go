@@ -0,0 +1,251 @@
+package localstack
+
+import (
+    "context"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "github.com/ory/dockertest"
+    "github.com/ory/dockertest/docker"
+    "github.com/mitchelldavis/go_localstack/pkg/mock_localstack"
+    "github.com/golang/mock/gomock"
+)
+
+func snapshotDir(t *testing.T) string {
+    dir, err := ioutil.TempDir("", "snapshot_test")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := os.MkdirAll(filepath.Join(dir, "dynamodb"), 0755); err != nil {
+        t.Fatal(err)
+    }
+    if err := ioutil.WriteFile(filepath.Join(dir, "dynamodb", "recorded_api_calls.json"), []byte("{}"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    return dir
+}
+
+func Test_Snapshot(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    source := snapshotDir(t)
+    defer os.RemoveAll(source)
+    target, _ := ioutil.TempDir("", "snapshot_test")
+    defer os.RemoveAll(target)
+
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+    ls := &Localstack {
+        Resource: &dockertest.Resource { Container: &docker.Container {
+            ID: "DummyID",
+            Mounts: []docker.Mount { docker.Mount { Source: source, Destination: Localstack_DataDir } },
+        }},
+        wrapper: m,
+    }
+
+    gomock.InOrder(
+        m.
+        EXPECT().
+        PauseContainer(gomock.Any(), "DummyID").
+        Return(nil),
+        m.
+        EXPECT().
+        UnpauseContainer(gomock.Any(), "DummyID").
+        Return(nil),
+    )
+
+    if err := ls.Snapshot(filepath.Join(target, "seeded")); err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if _, err := os.Stat(filepath.Join(target, "seeded", "dynamodb", "recorded_api_calls.json")); err != nil {
+        t.Errorf("The data directory should be copied into the snapshot: %s", err)
+    }
+}
+
+func Test_Snapshot_ContextCancelled(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    source := snapshotDir(t)
+    defer os.RemoveAll(source)
+    target, _ := ioutil.TempDir("", "snapshot_test")
+    defer os.RemoveAll(target)
+
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+    ls := &Localstack {
+        Resource: &dockertest.Resource { Container: &docker.Container {
+            ID: "DummyID",
+            Mounts: []docker.Mount { docker.Mount { Source: source, Destination: Localstack_DataDir } },
+        }},
+        wrapper: m,
+    }
+
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+
+    gomock.InOrder(
+        m.
+        EXPECT().
+        PauseContainer(gomock.Any(), "DummyID").
+        Return(nil),
+        m.
+        EXPECT().
+        UnpauseContainer(gomock.Any(), "DummyID").
+        DoAndReturn(func(ctx context.Context, id string) error {
+            return ctx.Err()
+        }),
+    )
+
+    if err := ls.SnapshotContext(ctx, filepath.Join(target, "seeded")); err != nil {
+        t.Errorf("The container should be unpaused even when the context is done: %s", err)
+    }
+}
+
+func Test_Snapshot_NoDataDir(t *testing.T) {
+    ls := &Localstack { Resource: &dockertest.Resource { Container: &docker.Container { ID: "DummyID" } } }
+
+    if err := ls.Snapshot(os.TempDir()); err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+}
+
+func Test_NewLocalstack_FromSnapshot(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    snapshot := snapshotDir(t)
+    defer os.RemoveAll(snapshot)
+
+    sqs, _ := NewLocalstackService("sqs")
+    services := &LocalstackServiceCollection { *sqs }
+    m := getLocalstack_Empty(services, ctrl)
+
+    var dataDir string
+    m.
+    EXPECT().
+    RunWithOptions(gomock.Any(), gomock.Any()).
+    Times(1).
+    DoAndReturn(func(ctx context.Context, opts *dockertest.RunOptions, hcOpts ...func(*docker.HostConfig)) (*dockertest.Resource, error) {
+        if !contains(opts.Env, "DATA_DIR=" + Localstack_DataDir) {
+            t.Errorf("DATA_DIR should be set.  Received %v", opts.Env)
+        }
+        if len(opts.Mounts) != 1 || !strings.HasSuffix(opts.Mounts[0], ":" + Localstack_DataDir) {
+            t.Fatalf("The data directory should be mounted.  Received %v", opts.Mounts)
+        }
+        dataDir = strings.TrimSuffix(opts.Mounts[0], ":" + Localstack_DataDir)
+        return &dockertest.Resource { Container: &docker.Container {
+            ID: "DummyID",
+            Mounts: []docker.Mount { docker.Mount { Source: dataDir, Destination: Localstack_DataDir } },
+        }}, nil
+    })
+
+    m.
+    EXPECT().
+    Retry(gomock.Any(), gomock.Any()).
+    Return(nil)
+
+    m.
+    EXPECT().
+    RemoveContainer(gomock.Any(), gomock.Any()).
+    Return(nil)
+
+    result, err := newLocalstack(context.Background(), services, m, WithName(Localstack_Name), WithPortMode(PortModeEdge), FromSnapshot(snapshot))
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if dataDir == snapshot {
+        t.Error("The snapshot itself should not be mounted.")
+    }
+    if _, err := os.Stat(filepath.Join(dataDir, "dynamodb", "recorded_api_calls.json")); err != nil {
+        t.Errorf("The snapshot should be copied into the data directory: %s", err)
+    }
+
+    if err := result.Destroy(); err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if _, err := os.Stat(dataDir); !os.IsNotExist(err) {
+        t.Error("The temporary data directory should be removed with the container.")
+    }
+    if _, err := os.Stat(snapshot); err != nil {
+        t.Error("The snapshot should be left untouched.")
+    }
+}
+
+func Test_CommitImage(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+    ls := &Localstack {
+        Resource: &dockertest.Resource { Container: &docker.Container { ID: "DummyID" } },
+        wrapper: m,
+    }
+
+    m.
+    EXPECT().
+    CommitContainer(gomock.Any(), gomock.Any()).
+    Times(1).
+    Do(func(ctx context.Context, opts docker.CommitContainerOptions) {
+        if opts.Container != "DummyID" || opts.Repository != "seeded/localstack" || opts.Tag != "v1" {
+            t.Errorf("The container should be committed to the image given.  Received %+v", opts)
+        }
+    }).
+    Return(&docker.Image{ }, nil)
+
+    if err := ls.CommitImage("seeded/localstack", "v1"); err != nil {
+        t.Errorf("We were expecting the returned error to be nil: %s", err)
+    }
+}
+
+func Test_CommitImage_DataDir(t *testing.T) {
+    ls := &Localstack { Resource: &dockertest.Resource { Container: &docker.Container {
+        ID: "DummyID",
+        Mounts: []docker.Mount { docker.Mount { Source: os.TempDir(), Destination: Localstack_DataDir } },
+    }}}
+
+    if err := ls.CommitImage("seeded/localstack", "v1"); err == nil {
+        t.Error("State in a data directory mount can't be committed.")
+    }
+}
+
+func Test_NewLocalstack_WithPersistence(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    sqs, _ := NewLocalstackService("sqs")
+    services := &LocalstackServiceCollection { *sqs }
+    m := getLocalstack_Empty(services, ctrl)
+
+    m.
+    EXPECT().
+    RunWithOptions(gomock.Any(), gomock.Any()).
+    Times(1).
+    Do(func(ctx context.Context, opts *dockertest.RunOptions, hcOpts ...func(*docker.HostConfig)) {
+        if !contains(opts.Env, "DATA_DIR=" + Localstack_DataDir) {
+            t.Errorf("DATA_DIR should be set.  Received %v", opts.Env)
+        }
+        if len(opts.Mounts) != 0 {
+            t.Errorf("The state should be kept in the container.  Received %v", opts.Mounts)
+        }
+    }).
+    Return(&dockertest.Resource{ }, nil)
+
+    m.
+    EXPECT().
+    Retry(gomock.Any(), gomock.Any()).
+    Return(nil)
+
+    if _, err := newLocalstack(context.Background(), services, m, WithName(Localstack_Name), WithPortMode(PortModeEdge), WithPersistence()); err != nil {
+        t.Errorf("We were expecting the returned error to be nil: %s", err)
+    }
+}
@@ -0,0 +1,237 @@
+package localstack
+
+import (
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/tls"
+    "crypto/x509"
+    "crypto/x509/pkix"
+    "encoding/pem"
+    "errors"
+    "fmt"
+    "io/ioutil"
+    "math/big"
+    "net"
+    "net/http"
+    "net/url"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+)
+
+// Localstack_CertFile is where Localstack looks for the certificate and key it
+// serves HTTPS with inside the container.  (See WithTLS)
+const Localstack_CertFile string = "/tmp/localstack/server.test.pem"
+
+// tlsPrefix names the temporary directories created by WithTLS.
+const tlsPrefix string = "go_localstack_tls_"
+
+const (
+    tlsCAFile = "ca.pem"
+    tlsServerFile = "server.pem"
+)
+
+// prepareTLS generates a CA and a certificate signed by it for Localstack into a
+// new temporary directory.  The certificate is valid for the addresses the
+// container is reached on from the host.
+func prepareTLS() (string, error) {
+    dir, err := ioutil.TempDir("", tlsPrefix)
+    if err != nil {
+        return "", errors.New(fmt.Sprintf("Unable to create a certificate directory: %s", err))
+    }
+    if err := generateCertificates(dir, tlsHosts()); err != nil {
+        os.RemoveAll(dir)
+        return "", errors.New(fmt.Sprintf("Unable to generate certificates: %s", err))
+    }
+    return dir, nil
+}
+
+// tlsHosts returns the host names and addresses the certificate is valid for.
+func tlsHosts() []string {
+    hosts := []string { "localhost", "127.0.0.1", "0.0.0.0", "::1" }
+    if docker, err := url.Parse(os.Getenv("DOCKER_HOST")); err == nil && docker.Hostname() != "" {
+        hosts = append(hosts, docker.Hostname())
+    }
+    return hosts
+}
+
+// generateCertificates writes a CA certificate to ca.pem and a certificate for the
+// hosts given, followed by its key, to server.pem.
+func generateCertificates(dir string, hosts []string) error {
+    now := time.Now()
+
+    caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        return err
+    }
+    ca := &x509.Certificate {
+        SerialNumber: big.NewInt(1),
+        Subject: pkix.Name { CommonName: "go_localstack CA" },
+        NotBefore: now.Add(-time.Hour),
+        NotAfter: now.Add(time.Hour * 24 * 365),
+        IsCA: true,
+        BasicConstraintsValid: true,
+        KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+    }
+    caDER, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+    if err != nil {
+        return err
+    }
+
+    serverKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        return err
+    }
+    server := &x509.Certificate {
+        SerialNumber: big.NewInt(2),
+        Subject: pkix.Name { CommonName: "localhost" },
+        NotBefore: now.Add(-time.Hour),
+        NotAfter: now.Add(time.Hour * 24 * 365),
+        KeyUsage: x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+        ExtKeyUsage: []x509.ExtKeyUsage { x509.ExtKeyUsageServerAuth },
+    }
+    for _, host := range hosts {
+        if ip := net.ParseIP(host); ip != nil {
+            server.IPAddresses = append(server.IPAddresses, ip)
+        } else {
+            server.DNSNames = append(server.DNSNames, host)
+        }
+    }
+    caCert, err := x509.ParseCertificate(caDER)
+    if err != nil {
+        return err
+    }
+    serverDER, err := x509.CreateCertificate(rand.Reader, server, caCert, &serverKey.PublicKey, caKey)
+    if err != nil {
+        return err
+    }
+    keyDER, err := x509.MarshalECPrivateKey(serverKey)
+    if err != nil {
+        return err
+    }
+
+    caPEM := pem.EncodeToMemory(&pem.Block { Type: "CERTIFICATE", Bytes: caDER })
+    serverPEM := append(
+        pem.EncodeToMemory(&pem.Block { Type: "CERTIFICATE", Bytes: serverDER }),
+        pem.EncodeToMemory(&pem.Block { Type: "EC PRIVATE KEY", Bytes: keyDER })...)
+
+    if err := ioutil.WriteFile(filepath.Join(dir, tlsCAFile), caPEM, 0644); err != nil {
+        return err
+    }
+    // Localstack may run as a different user than the one running the tests.
+    return ioutil.WriteFile(filepath.Join(dir, tlsServerFile), serverPEM, 0644)
+}
+
+// tlsDir returns the host directory of the certificates generated by WithTLS.
+func (ls *Localstack) tlsDir() (string, bool) {
+    if ls.Resource == nil || ls.Resource.Container == nil {
+        return "", false
+    }
+    for _, mount := range ls.Resource.Container.Mounts {
+        if mount.Destination == Localstack_CertFile {
+            return filepath.Dir(mount.Source), true
+        }
+    }
+    return "", false
+}
+
+// removeTLSDir removes the certificates generated by WithTLS.  (See removeSnapshotDataDir)
+func (ls *Localstack) removeTLSDir() error {
+    dir, ok := ls.tlsDir()
+    if !ok || !strings.HasPrefix(filepath.Base(dir), tlsPrefix) {
+        return nil
+    }
+    if err := os.RemoveAll(dir); err != nil {
+        return errors.New(fmt.Sprintf("Unable to remove certificate directory %s: %s", dir, err))
+    }
+    return nil
+}
+
+// scheme returns the scheme of the URLs the services are reached on.
+func (ls *Localstack) scheme() string {
+    if _, ok := ls.tlsDir(); ok {
+        return "https"
+    }
+    return "http"
+}
+
+// CACertificate returns the path of the CA certificate that Localstack's certificate
+// is signed by.  ok is false when Localstack wasn't started with WithTLS.
+func (ls *Localstack) CACertificate() (path string, ok bool) {
+    dir, ok := ls.tlsDir()
+    if !ok {
+        return "", false
+    }
+    return filepath.Join(dir, tlsCAFile), true
+}
+
+// HTTPClient returns an http.Client that trusts Localstack's certificate as well
+// as the system's.  (See WithTLS)  Without TLS, it is a plain http.Client.  The
+// clients returned share one transport, which is created the first time.
+func (ls *Localstack) HTTPClient() (*http.Client, error) {
+    if _, ok := ls.CACertificate(); !ok {
+        return &http.Client{ }, nil
+    }
+    return ls.trustingClient(nil)
+}
+
+// trustingClient returns a copy of the client given whose transport trusts
+// Localstack's certificate.  The client given, and its transport, are left
+// as they are.  A nil client is treated like http.DefaultClient.
+func (ls *Localstack) trustingClient(client *http.Client) (*http.Client, error) {
+    pool, transport, err := ls.tlsTransport()
+    if err != nil {
+        return nil, err
+    }
+    if client == nil {
+        return &http.Client { Transport: transport }, nil
+    }
+
+    result := *client
+    switch t := client.Transport.(type) {
+    case nil:
+        result.Transport = transport
+    case *http.Transport:
+        clone := t.Clone()
+        if clone.TLSClientConfig == nil {
+            clone.TLSClientConfig = &tls.Config{ }
+        }
+        clone.TLSClientConfig.RootCAs = pool
+        result.Transport = clone
+    default:
+        return nil, errors.New(fmt.Sprintf("Unable to trust the CA certificate with a transport of type %T", t))
+    }
+    return &result, nil
+}
+
+// tlsTransport returns the pool of the system's certificates and Localstack's
+// CA, and a transport that trusts it, creating them the first time.
+func (ls *Localstack) tlsTransport() (*x509.CertPool, *http.Transport, error) {
+    cache := ls.clientCache()
+    cache.tlsMutex.Lock()
+    defer cache.tlsMutex.Unlock()
+
+    if cache.tlsTransport != nil {
+        return cache.tlsPool, cache.tlsTransport, nil
+    }
+
+    path, _ := ls.CACertificate()
+    ca, err := ioutil.ReadFile(path)
+    if err != nil {
+        return nil, nil, errors.New(fmt.Sprintf("Unable to read the CA certificate: %s", err))
+    }
+    pool, err := x509.SystemCertPool()
+    if err != nil {
+        pool = x509.NewCertPool()
+    }
+    if !pool.AppendCertsFromPEM(ca) {
+        return nil, nil, errors.New(fmt.Sprintf("Unable to parse the CA certificate %s", path))
+    }
+
+    transport := http.DefaultTransport.(*http.Transport).Clone()
+    transport.TLSClientConfig = &tls.Config { RootCAs: pool }
+    cache.tlsPool, cache.tlsTransport = pool, transport
+    return pool, transport, nil
+}
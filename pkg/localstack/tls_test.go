@@ -0,0 +1,179 @@
+package localstack
+
+import (
+    "context"
+    "crypto/tls"
+    "crypto/x509"
+    "encoding/pem"
+    "io/ioutil"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "github.com/ory/dockertest"
+    "github.com/ory/dockertest/docker"
+    "github.com/golang/mock/gomock"
+    "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+func Test_generateCertificates(t *testing.T) {
+    dir, _ := ioutil.TempDir("", "tls_test")
+    defer os.RemoveAll(dir)
+
+    if err := generateCertificates(dir, []string { "localhost", "127.0.0.1", "docker.example.com" }); err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    ca, _ := ioutil.ReadFile(filepath.Join(dir, tlsCAFile))
+    pool := x509.NewCertPool()
+    if !pool.AppendCertsFromPEM(ca) {
+        t.Fatal("The CA certificate should be a PEM certificate.")
+    }
+
+    server, _ := ioutil.ReadFile(filepath.Join(dir, tlsServerFile))
+    block, rest := pem.Decode(server)
+    if block == nil || block.Type != "CERTIFICATE" {
+        t.Fatal("The server file should start with the certificate.")
+    }
+    if key, _ := pem.Decode(rest); key == nil || !strings.HasSuffix(key.Type, "PRIVATE KEY") {
+        t.Fatal("The server file should end with the key.")
+    }
+
+    certificate, _ := x509.ParseCertificate(block.Bytes)
+    for _, host := range []string { "localhost", "127.0.0.1", "docker.example.com" } {
+        if _, err := certificate.Verify(x509.VerifyOptions { DNSName: host, Roots: pool }); err != nil {
+            t.Errorf("The certificate should be valid for %s: %s", host, err)
+        }
+    }
+}
+
+func Test_TLS_Session(t *testing.T) {
+    dir, _ := ioutil.TempDir("", tlsPrefix)
+    defer os.RemoveAll(dir)
+    if err := generateCertificates(dir, []string { "127.0.0.1" }); err != nil {
+        t.Fatal(err)
+    }
+
+    serverFile := filepath.Join(dir, tlsServerFile)
+    certificate, err := tls.LoadX509KeyPair(serverFile, serverFile)
+    if err != nil {
+        t.Fatal(err)
+    }
+    server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if strings.HasSuffix(r.URL.Path, "/health") {
+            w.Write([]byte(`{"services": {"sqs": "running"}}`))
+            return
+        }
+        w.Write([]byte(`<ListQueuesResponse><ListQueuesResult></ListQueuesResult></ListQueuesResponse>`))
+    }))
+    server.TLS = &tls.Config { Certificates: []tls.Certificate { certificate } }
+    server.StartTLS()
+    defer server.Close()
+
+    container := healthContainer(server)
+    container.Mounts = []docker.Mount { docker.Mount { Source: serverFile, Destination: Localstack_CertFile } }
+    sqsService, _ := NewLocalstackService("sqs")
+    ls := &Localstack {
+        Resource: &dockertest.Resource { Container: container },
+        Services: &LocalstackServiceCollection { *sqsService },
+        portMode: PortModeEdge,
+    }
+
+    if _, err := ls.Health(context.Background()); err != nil {
+        t.Errorf("The health endpoint should be reached over HTTPS: %s", err)
+    }
+
+    if err := WaitForHTTP(Localstack_EdgePort, "/_localstack/health", http.StatusOK).Ready(context.Background(), ls); err != nil {
+        t.Errorf("WaitForHTTP should reach Localstack over HTTPS: %s", err)
+    }
+
+    defaultTransport := http.DefaultClient.Transport
+    svc := sqs.New(ls.CreateAWSSession())
+    if http.DefaultClient.Transport != defaultTransport {
+        t.Error("Creating a session shouldn't change http.DefaultClient.")
+    }
+    if !strings.HasPrefix(svc.Endpoint, "https://") {
+        t.Errorf("The endpoint should use HTTPS.  Received %s", svc.Endpoint)
+    }
+    if _, err := svc.ListQueues(&sqs.ListQueuesInput{ }); err != nil {
+        t.Errorf("The session should trust the CA: %s", err)
+    }
+
+    transport := &http.Transport{ }
+    sess, err := ls.CreateAWSSessionWithOptions(SessionOptions { HTTPClient: &http.Client { Transport: transport } })
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+    if transport.TLSClientConfig != nil && transport.TLSClientConfig.RootCAs != nil {
+        t.Error("Creating a session shouldn't change the transport of the client given.")
+    }
+    if _, err := sqs.New(sess).ListQueues(&sqs.ListQueuesInput{ }); err != nil {
+        t.Errorf("The session should trust the CA with the client given: %s", err)
+    }
+
+    first, _ := ls.HTTPClient()
+    second, _ := ls.HTTPClient()
+    if first.Transport != second.Transport {
+        t.Error("The clients returned by HTTPClient should share their transport.")
+    }
+
+    if !contains(ls.Env(), "AWS_CA_BUNDLE=" + filepath.Join(dir, tlsCAFile)) {
+        t.Errorf("Subprocesses should trust the CA.  Received %v", ls.Env())
+    }
+}
+
+func Test_NewLocalstack_WithTLS(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    sqsService, _ := NewLocalstackService("sqs")
+    services := &LocalstackServiceCollection { *sqsService }
+    m := getLocalstack_Empty(services, ctrl)
+
+    var serverFile string
+    m.
+    EXPECT().
+    RunWithOptions(gomock.Any(), gomock.Any()).
+    DoAndReturn(func(ctx context.Context, opts *dockertest.RunOptions, hcOpts ...func(*docker.HostConfig)) (*dockertest.Resource, error) {
+        if !contains(opts.Env, "USE_SSL=1") {
+            t.Errorf("USE_SSL should be set.  Received %v", opts.Env)
+        }
+        if len(opts.Mounts) != 1 || !strings.HasSuffix(opts.Mounts[0], ":" + Localstack_CertFile) {
+            t.Fatalf("The certificate should be mounted.  Received %v", opts.Mounts)
+        }
+        serverFile = strings.TrimSuffix(opts.Mounts[0], ":" + Localstack_CertFile)
+        return &dockertest.Resource { Container: &docker.Container {
+            ID: "DummyID",
+            Mounts: []docker.Mount { docker.Mount { Source: serverFile, Destination: Localstack_CertFile } },
+        }}, nil
+    })
+
+    m.
+    EXPECT().
+    Retry(gomock.Any(), gomock.Any()).
+    Return(nil)
+
+    m.
+    EXPECT().
+    RemoveContainer(gomock.Any(), gomock.Any()).
+    Return(nil)
+
+    result, err := newLocalstack(context.Background(), services, m, WithName(Localstack_Name), WithPortMode(PortModeEdge), WithTLS())
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if _, ok := result.CACertificate(); !ok {
+        t.Error("The CA certificate should be available.")
+    }
+
+    if err := result.Destroy(); err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+    if _, err := os.Stat(filepath.Dir(serverFile)); !os.IsNotExist(err) {
+        t.Error("The certificates should be removed with the container.")
+    }
+}
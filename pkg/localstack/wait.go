@@ -0,0 +1,173 @@
+package localstack
+
+import (
+    "bufio"
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "net"
+    "net/http"
+    "regexp"
+    "strings"
+    "time"
+    "github.com/ory/dockertest/docker"
+)
+
+// Localstack_ReadyPattern matches the line Localstack logs once it is ready.
+var Localstack_ReadyPattern = regexp.MustCompile(`^Ready\.$`)
+
+// WaitStrategy decides when a Localstack container is ready to be used.
+type WaitStrategy interface {
+    // Ready returns nil once the Localstack instance is ready.  It is
+    // called repeatedly until it returns nil or the readiness timeout
+    // is reached.
+    Ready(ctx context.Context, ls *Localstack) error
+}
+
+// defaultWaitStrategy returns the strategy used for a port mode when
+// one isn't given.  (See WithWaitStrategy)
+func defaultWaitStrategy(mode PortMode) WaitStrategy {
+    switch mode {
+    case PortModeLegacy:
+        return WaitForLog(Localstack_ReadyPattern)
+    case PortModeEdge:
+        return WaitForHealth()
+    default:
+        return WaitForAny(WaitForHealth(), WaitForLog(Localstack_ReadyPattern))
+    }
+}
+
+// WaitStrategyFunc allows a plain function to be used as a WaitStrategy.
+type WaitStrategyFunc func(ctx context.Context, ls *Localstack) error
+
+// Ready simply calls the function.
+func (f WaitStrategyFunc) Ready(ctx context.Context, ls *Localstack) error {
+    return f(ctx, ls)
+}
+
+// WaitForHealth waits for Localstack's health endpoint to report every
+// requested service as up.  This is the default strategy with the edge port.
+func WaitForHealth() WaitStrategy {
+    return WaitStrategyFunc(func(ctx context.Context, ls *Localstack) error {
+        report, err := ls.Health(ctx)
+        if err != nil {
+            return err
+        }
+        if down := report.Down(ls.Services); len(down) > 0 {
+            return errors.New(fmt.Sprintf("Services never came up: %s", strings.Join(down, ", ")))
+        }
+        return nil
+    })
+}
+
+// WaitForLog waits for a line in the container's logs to match the pattern given.
+// (I.E. WaitForLog(regexp.MustCompile("^Ready\\.$")))
+func WaitForLog(pattern *regexp.Regexp) WaitStrategy {
+    return WaitStrategyFunc(func(ctx context.Context, ls *Localstack) error {
+        buffer := new(bytes.Buffer)
+        err := ls.dockerWrapper().Logs(ctx, docker.LogsOptions {
+            Container: ls.Resource.Container.ID,
+            OutputStream: buffer,
+            ErrorStream: buffer,
+            Stdout: true,
+            Stderr: true,
+        })
+        if err != nil {
+            return errors.New(fmt.Sprintf("Unable to retrieve logs for container %s: %s", ls.Resource.Container.ID, err))
+        }
+
+        scanner := bufio.NewScanner(buffer)
+        for scanner.Scan() {
+            if pattern.MatchString(strings.TrimSpace(scanner.Text())) {
+                return nil
+            }
+        }
+        if err := scanner.Err(); err != nil {
+            return errors.New(fmt.Sprintf("Reading input: %s", err))
+        }
+        return errors.New(fmt.Sprintf("No log line matched %s", pattern))
+    })
+}
+
+// WaitForHTTP waits for a GET request to the path on the container port given
+// (I.E. "4566/tcp") to return the status code given.  HTTPS is used with WithTLS.
+func WaitForHTTP(port, path string, status int) WaitStrategy {
+    return WaitStrategyFunc(func(ctx context.Context, ls *Localstack) error {
+        hostPort := ls.Resource.GetHostPort(port)
+        if hostPort == "" {
+            return errors.New(fmt.Sprintf("The container doesn't expose the port %s", port))
+        }
+
+        request, err := http.NewRequest("GET", fmt.Sprintf("%s://%s%s", ls.scheme(), hostPort, path), nil)
+        if err != nil {
+            return err
+        }
+
+        client, err := ls.HTTPClient()
+        if err != nil {
+            return err
+        }
+        client.Timeout = time.Second * 5
+        response, err := client.Do(request.WithContext(ctx))
+        if err != nil {
+            return err
+        }
+        response.Body.Close()
+
+        if response.StatusCode != status {
+            return errors.New(fmt.Sprintf("Expected the status %d from %s but received %d", status, path, response.StatusCode))
+        }
+        return nil
+    })
+}
+
+// WaitForPort waits for the container port given (I.E. "4566/tcp") to
+// accept TCP connections.
+func WaitForPort(port string) WaitStrategy {
+    return WaitStrategyFunc(func(ctx context.Context, ls *Localstack) error {
+        hostPort := ls.Resource.GetHostPort(port)
+        if hostPort == "" {
+            return errors.New(fmt.Sprintf("The container doesn't expose the port %s", port))
+        }
+
+        dialer := &net.Dialer { Timeout: time.Second * 5 }
+        conn, err := dialer.DialContext(ctx, "tcp", hostPort)
+        if err != nil {
+            return err
+        }
+        return conn.Close()
+    })
+}
+
+// WaitForFunc waits for the function given to return nil.
+func WaitForFunc(ready func(ctx context.Context, ls *Localstack) error) WaitStrategy {
+    return WaitStrategyFunc(ready)
+}
+
+// WaitForAll is ready when every one of the strategies given is ready.
+func WaitForAll(strategies ...WaitStrategy) WaitStrategy {
+    return WaitStrategyFunc(func(ctx context.Context, ls *Localstack) error {
+        for _, strategy := range strategies {
+            if err := strategy.Ready(ctx, ls); err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+}
+
+// WaitForAny is ready as soon as one of the strategies given is ready.
+func WaitForAny(strategies ...WaitStrategy) WaitStrategy {
+    return WaitStrategyFunc(func(ctx context.Context, ls *Localstack) error {
+        var messages []string
+        for _, strategy := range strategies {
+            err := strategy.Ready(ctx, ls)
+            if err == nil {
+                return nil
+            }
+            messages = append(messages, err.Error())
+        }
+        return errors.New(strings.Join(messages, "; "))
+    })
+}
@@ -0,0 +1,186 @@
+package localstack
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "regexp"
+    "testing"
+    "github.com/ory/dockertest"
+    "github.com/ory/dockertest/docker"
+    "github.com/ory/dockertest/docker/pkg/stdcopy"
+    "github.com/mitchelldavis/go_localstack/pkg/mock_localstack"
+    "github.com/golang/mock/gomock"
+)
+
+var readyStrategy = WaitForFunc(func(ctx context.Context, ls *Localstack) error { return nil })
+var notReadyStrategy = WaitForFunc(func(ctx context.Context, ls *Localstack) error { return errors.New("Not Ready") })
+
+func Test_WaitForLog(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+
+    m.
+    EXPECT().
+    Logs(gomock.Any(), gomock.Any()).
+    Times(2).
+    DoAndReturn(func(ctx context.Context, opts docker.LogsOptions) error {
+        if opts.Container != "DummyID" {
+            t.Errorf("The logs were requested for the wrong container: %s", opts.Container)
+        }
+        opts.OutputStream.Write([]byte("Starting mock services\n  Patched Ready!\n"))
+        return nil
+    })
+
+    ls := &Localstack {
+        Resource: &dockertest.Resource { Container: &docker.Container { ID: "DummyID" } },
+        wrapper: m,
+    }
+
+    if err := WaitForLog(regexp.MustCompile("^Patched Ready!$")).Ready(context.Background(), ls); err != nil {
+        t.Errorf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if err := WaitForLog(regexp.MustCompile("^Ready\\.$")).Ready(context.Background(), ls); err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+}
+
+// dockerFrame returns the bytes of a multiplexed log stream, as sent by
+// docker for containers without a TTY.
+func dockerFrame(stream stdcopy.StdType, line string) []byte {
+    frame := bytes.NewBuffer(nil)
+    stdcopy.NewStdWriter(frame, stream).Write([]byte(line))
+    return frame.Bytes()
+}
+
+func Test_WaitForLog_Multiplexed(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    m := mock_localstack.NewMockDockerWrapper(ctrl)
+
+    m.
+    EXPECT().
+    Logs(gomock.Any(), gomock.Any()).
+    Times(1).
+    DoAndReturn(func(ctx context.Context, opts docker.LogsOptions) error {
+        stream := append(dockerFrame(stdcopy.Stderr, "Starting mock services\n"), dockerFrame(stdcopy.Stdout, "Ready.\n")...)
+        if opts.RawTerminal {
+            opts.OutputStream.Write(stream)
+            return nil
+        }
+        // The docker client demultiplexes the stream unless RawTerminal is set.
+        _, err := stdcopy.StdCopy(opts.OutputStream, opts.ErrorStream, bytes.NewReader(stream))
+        return err
+    })
+
+    ls := &Localstack {
+        Resource: &dockertest.Resource { Container: &docker.Container { ID: "DummyID" } },
+        wrapper: m,
+    }
+
+    if err := WaitForLog(regexp.MustCompile("^Ready\\.$")).Ready(context.Background(), ls); err != nil {
+        t.Errorf("We were expecting the returned error to be nil: %s", err)
+    }
+}
+
+func Test_WaitForHTTP(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.URL.Path != "/ready" {
+            http.NotFound(w, r)
+        }
+    }))
+    defer server.Close()
+
+    ls := &Localstack { Resource: &dockertest.Resource { Container: healthContainer(server) } }
+
+    if err := WaitForHTTP(Localstack_EdgePort, "/ready", http.StatusOK).Ready(context.Background(), ls); err != nil {
+        t.Errorf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if err := WaitForHTTP(Localstack_EdgePort, "/missing", http.StatusOK).Ready(context.Background(), ls); err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+}
+
+func Test_WaitForPort(t *testing.T) {
+    server := httptest.NewServer(http.NotFoundHandler())
+
+    ls := &Localstack { Resource: &dockertest.Resource { Container: healthContainer(server) } }
+
+    if err := WaitForPort(Localstack_EdgePort).Ready(context.Background(), ls); err != nil {
+        t.Errorf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if err := WaitForPort("4567/tcp").Ready(context.Background(), ls); err == nil {
+        t.Error("An unexposed port should never be ready.")
+    }
+
+    server.Close()
+
+    if err := WaitForPort(Localstack_EdgePort).Ready(context.Background(), ls); err == nil {
+        t.Error("A closed port should not be ready.")
+    }
+}
+
+func Test_WaitForAll(t *testing.T) {
+    if err := WaitForAll(readyStrategy, readyStrategy).Ready(context.Background(), nil); err != nil {
+        t.Errorf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if err := WaitForAll(readyStrategy, notReadyStrategy).Ready(context.Background(), nil); err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+}
+
+func Test_WaitForAny(t *testing.T) {
+    if err := WaitForAny(notReadyStrategy, readyStrategy).Ready(context.Background(), nil); err != nil {
+        t.Errorf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if err := WaitForAny(notReadyStrategy, notReadyStrategy).Ready(context.Background(), nil); err == nil {
+        t.Error("We were expecting the returned error to be populated.")
+    }
+}
+
+func Test_NewLocalstack_WithWaitStrategy(t *testing.T) {
+    ctrl := gomock.NewController(t)
+
+    defer ctrl.Finish()
+
+    sqs, _ := NewLocalstackService("sqs")
+    services := &LocalstackServiceCollection {
+        *sqs,
+    }
+    m, c := getLocalstack_Found(services, ctrl)
+
+    m.
+    EXPECT().
+    Retry(gomock.Any(), gomock.Any()).
+    Times(1).
+    DoAndReturn(func(ctx context.Context, op func() error) error {
+        return op()
+    })
+
+    var received *Localstack
+    strategy := WaitForFunc(func(ctx context.Context, ls *Localstack) error {
+        received = ls
+        return nil
+    })
+
+    result, err := newLocalstack(context.Background(), services, m, append(testOptions, WithWaitStrategy(strategy))...)
+    if err != nil {
+        t.Fatalf("We were expecting the returned error to be nil: %s", err)
+    }
+
+    if received != result || result.Resource.Container != c {
+        t.Error("The wait strategy should receive the Localstack being started.")
+    }
+}